@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"marketflow/internal/adapters/postgres"
+	"marketflow/internal/adapters/redis"
+	"marketflow/internal/app/aggregator"
+	"marketflow/internal/app/rollup"
+	"marketflow/internal/domain"
+)
+
+// runAggregate implements `marketflow aggregate`: it recomputes the minute
+// aggregates of a range from the ticks in Redis, then the rollups over it.
+func runAggregate(args []string) int {
+	fs, configPath := newFlagSet("aggregate")
+	from := fs.String("from", "", "start of the range, RFC3339 or unix seconds")
+	to := fs.String("to", "", "end of the range (default now)")
+	period := fs.String("period", "", "range relative to -to or now, e.g. 2h or last_hour")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	rng, err := domain.ResolveTimeRange(*period, *from, *to, time.Now())
+	if err == nil && rng.From.IsZero() {
+		err = fmt.Errorf("-from or -period is required")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow aggregate:", err)
+		return 2
+	}
+	if rng.To.IsZero() {
+		rng.To = time.Now()
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		slog.Error("Failed to load config", "err", err)
+		return 1
+	}
+	if err := errors.Join(requirePostgres(cfg, "aggregate"), requireRedis(cfg, "aggregate")); err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow aggregate:", err)
+		return 2
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	pgAdapter, err := postgres.NewPostgresAdapter(cfg.Postgres.DSN())
+	if err != nil {
+		slog.Error("Postgres connection error", "err", err)
+		return 1
+	}
+	defer pgAdapter.Close()
+	redisAdapter := redis.NewRedisAdapter(cfg.Redis.Addr(), cfg.Redis.Password, cfg.Redis.DB)
+	defer redisAdapter.Close()
+
+	retention, _ := cfg.TickRetention()
+	resolutions, _ := cfg.Resolutions()
+	service := aggregator.NewServiceCom(redisAdapter, pgAdapter, cfg.Registry(), nil, retention)
+
+	windows, err := service.Reaggregate(ctx, rng.From, rng.To)
+	if errors.Is(err, domain.ErrInvalidArgument) {
+		fmt.Fprintln(os.Stderr, "marketflow aggregate:", err)
+		return 2
+	}
+	if err != nil {
+		slog.Error("Aggregation failed", "windows_done", windows, "err", err)
+		return 1
+	}
+	if err := rollup.NewJob(pgAdapter, resolutions).RollUp(ctx, rng.From, rng.To); err != nil {
+		slog.Error("Rollup failed", "err", err)
+		return 1
+	}
+	slog.Info("Aggregation complete", "from", rng.From, "to", rng.To, "windows", windows)
+	return 0
+}
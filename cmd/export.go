@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"marketflow/internal/adapters/postgres"
+	"marketflow/internal/app/api"
+	"marketflow/internal/domain"
+	"marketflow/internal/parquet"
+)
+
+// runExport implements `marketflow export`: it writes the stored minute
+// aggregates of a range as CSV or Parquet, one series after another, in time
+// order.
+func runExport(args []string) int {
+	fs, configPath := newFlagSet("export")
+	format := fs.String("format", "csv", "output format: csv or parquet")
+	symbols := fs.String("symbols", "", "comma-separated symbols (default all)")
+	exchange := fs.String("exchange", "", "only this exchange (default all)")
+	from := fs.String("from", "", "start of the range, RFC3339 or unix seconds")
+	to := fs.String("to", "", "end of the range (default unbounded)")
+	period := fs.String("period", "", "range relative to -to or now, e.g. 1d or yesterday")
+	out := fs.String("out", "-", "output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *format != "csv" && *format != "parquet" {
+		fmt.Fprintf(os.Stderr, "marketflow export: unsupported format %q, expected csv or parquet\n", *format)
+		return 2
+	}
+	rng, err := domain.ResolveTimeRange(*period, *from, *to, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow export:", err)
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		slog.Error("Failed to load config", "err", err)
+		return 1
+	}
+	if err := requirePostgres(cfg, "export"); err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow export:", err)
+		return 2
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	apiAdapter, err := postgres.NewApiAdapter(cfg.Postgres.DSN())
+	if err != nil {
+		slog.Error("Postgres connection error", "err", err)
+		return 1
+	}
+	defer apiAdapter.Close()
+	registry := cfg.Registry()
+	resolutions, _ := cfg.Resolutions()
+	// History reads Postgres only, so no tick store is needed.
+	service := api.NewService(apiAdapter, nil, registry, resolutions)
+
+	series := registry.Symbols()
+	if *symbols != "" {
+		if series, err = service.ResolveSymbols(strings.Split(*symbols, ",")); err != nil {
+			fmt.Fprintln(os.Stderr, "marketflow export:", err)
+			return 2
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			slog.Error("Failed to create output file", "path", *out, "err", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+	var rw exportRows = newCSVRows(buf)
+	if *format == "parquet" {
+		rw = newParquetRows(buf)
+	}
+
+	rows := 0
+	for _, symbol := range series {
+		cursor := ""
+		for {
+			page, err := service.GetHistory(ctx, *exchange, symbol, rng, api.MaxHistoryLimit, cursor)
+			if err != nil {
+				slog.Error("Export failed", "symbol", symbol, "rows_written", rows, "err", err)
+				return 1
+			}
+			for _, p := range page.Items {
+				if err := rw.Write(p); err != nil {
+					slog.Error("Failed to write export", "rows_written", rows, "err", err)
+					return 1
+				}
+				rows++
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+	}
+
+	if err := errors.Join(rw.Close(), buf.Flush()); err != nil {
+		slog.Error("Failed to write export", "err", err)
+		return 1
+	}
+	slog.Info("Export complete", "rows", rows)
+	return 0
+}
+
+// exportRows writes exported aggregates in one output format.
+type exportRows interface {
+	Write(p domain.AggregatedResponse) error
+	Close() error
+}
+
+type csvRows struct {
+	cw *csv.Writer
+}
+
+func newCSVRows(w io.Writer) csvRows {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"pair_name", "exchange", "window_start", "average_price", "min_price", "max_price", "interval"})
+	return csvRows{cw}
+}
+
+func (r csvRows) Write(p domain.AggregatedResponse) error {
+	_ = r.cw.Write([]string{
+		p.Pair,
+		p.Exchange,
+		p.Timestamp,
+		strconv.FormatFloat(p.Avg, 'f', -1, 64),
+		strconv.FormatFloat(p.Min, 'f', -1, 64),
+		strconv.FormatFloat(p.Max, 'f', -1, 64),
+		"1m",
+	})
+	return r.cw.Error()
+}
+
+func (r csvRows) Close() error {
+	r.cw.Flush()
+	return r.cw.Error()
+}
+
+// parquetRows writes the CSV columns with window_start as a UTC millisecond
+// timestamp rather than text.
+type parquetRows struct {
+	pw *parquet.Writer
+}
+
+func newParquetRows(w io.Writer) parquetRows {
+	return parquetRows{parquet.NewWriter(w,
+		parquet.Column{Name: "pair_name", Type: parquet.String},
+		parquet.Column{Name: "exchange", Type: parquet.String},
+		parquet.Column{Name: "window_start", Type: parquet.Timestamp},
+		parquet.Column{Name: "average_price", Type: parquet.Double},
+		parquet.Column{Name: "min_price", Type: parquet.Double},
+		parquet.Column{Name: "max_price", Type: parquet.Double},
+		parquet.Column{Name: "interval", Type: parquet.String},
+	)}
+}
+
+func (r parquetRows) Write(p domain.AggregatedResponse) error {
+	start, err := time.Parse(time.RFC3339, p.Timestamp)
+	if err != nil {
+		return fmt.Errorf("window start of %s/%s: %w", p.Pair, p.Exchange, err)
+	}
+	return r.pw.Write(p.Pair, p.Exchange, start, p.Avg, p.Min, p.Max, "1m")
+}
+
+func (r parquetRows) Close() error { return r.pw.Close() }
@@ -1,99 +1,83 @@
 package main
 
 import (
-	"context"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
-	"net/http"
 	"os"
-	"os/signal"
-	"time"
+	"strings"
 
-	"marketflow/internal/adapters/postgres"
-	"marketflow/internal/adapters/redis"
-	"marketflow/internal/app/aggregator"
-	"marketflow/internal/app/api"
-	"marketflow/internal/app/mode"
-	"marketflow/internal/domain"
-	"marketflow/internal/handler"
+	"marketflow/internal/config"
+	"marketflow/internal/logging"
 
 	_ "github.com/lib/pq"
 )
 
-func main() {
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, nil)))
-
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
+const usage = `usage: marketflow <command> [flags]
 
-	connStr := "host=postgres port=5432 user=market password=secret dbname=marketdb sslmode=disable"
-	pgAdapter, err := postgres.NewPostgresAdapter(connStr)
-	if err != nil {
-		slog.Error("Postgres connection error", "err", err)
-		os.Exit(1)
-	}
-	defer pgAdapter.Close()
+commands:
+  serve                  run the service (default)
+  migrate up|down|status apply, revert or list schema migrations
+  aggregate              recompute aggregates from the ticks in Redis
+  export                 write stored aggregates as CSV or Parquet
+  import                 load aggregates or raw ticks from CSV/NDJSON
+  mode get|set           read or switch the mode of a running instance
 
-	apiAdapter, err := postgres.NewApiAdapter(connStr)
-	if err != nil {
-		slog.Error("API adapter connection error", "err", err)
-		os.Exit(1)
-	}
-	defer apiAdapter.Close()
+Run "marketflow <command> -h" for the flags of a command.`
 
-	redisAdapter := redis.NewRedisAdapter("redis:6379", "", 0)
-	defer redisAdapter.Close()
+// logOutput receives the logs: stdout for the service, stderr for the other
+// commands so their own output stays clean.
+var logOutput io.Writer = os.Stderr
 
-	updates := make(chan domain.PriceUpdate, 1000)
-	modeManager := mode.NewModeManager(updates)
-	modeManager.SetMode(ctx, mode.ModeLive)
-
-	service := aggregator.NewServiceCom(redisAdapter, pgAdapter)
-	service.StartRedisWorkerPool(ctx, updates, 5)
-	go service.StartAggregator(ctx)
-
-	apiService := api.NewService(apiAdapter)
-	apiHandler := handler.NewHandler(apiService, modeManager)
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/prices/latest/", apiHandler.Handle)
-	mux.HandleFunc("/prices/highest/", apiHandler.Highest)
-	mux.HandleFunc("/prices/lowest/", apiHandler.Lowest)
-	mux.HandleFunc("/prices/average/", apiHandler.Average)
-	mux.HandleFunc("/mode/test", apiHandler.SwitchToTestMode)
-	mux.HandleFunc("/mode/live", apiHandler.SwitchToLiveMode)
-
-	healthHandler := &handler.HealthHandler{
-		DB:    apiAdapter,
-		Redis: redisAdapter,
+func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
-	mux.Handle("/health", healthHandler)
-
-	server := &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
+	if command == "serve" {
+		logOutput = os.Stdout
 	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(logOutput, nil)))
+
+	switch command {
+	case "serve":
+		os.Exit(runServe(args))
+	case "migrate":
+		os.Exit(runMigrate(args))
+	case "aggregate":
+		os.Exit(runAggregate(args))
+	case "export":
+		os.Exit(runExport(args))
+	case "import":
+		os.Exit(runImport(args))
+	case "mode":
+		os.Exit(runMode(args))
+	case "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", command, usage)
+		os.Exit(2)
+	}
+}
 
-	go func() {
-		slog.Info("Starting HTTP server", "address", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("HTTP server failed", "err", err)
-			cancel()
-		}
-	}()
-
-	slog.Info("Service is running")
-
-	<-ctx.Done()
-	slog.Info("Shutdown signal received")
-
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
+// newFlagSet starts a subcommand's flags with the shared -config flag.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("marketflow "+name, flag.ContinueOnError)
+	path := fs.String("config", config.DefaultPath, "path to the config file (CONFIG_PATH takes precedence)")
+	return fs, path
+}
 
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		slog.Error("Graceful shutdown failed", "err", err)
-	} else {
-		slog.Info("HTTP server shutdown complete")
+// loadConfig loads the config at path and installs the configured logger.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
 	}
-
-	slog.Info("Application shutdown complete")
+	logger, err := logging.New(logOutput, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return cfg, nil
 }
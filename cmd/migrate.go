@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"marketflow/internal/adapters/postgres"
+)
+
+const migrateUsage = "usage: marketflow migrate [-config path] up | down [steps] | status"
+
+// runMigrate implements `marketflow migrate`, returning the exit code.
+func runMigrate(args []string) int {
+	fs, configPath := newFlagSet("migrate")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	args = fs.Args()
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		slog.Error("Failed to load config", "err", err)
+		return 1
+	}
+	if err := requirePostgres(cfg, "migrate"); err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow migrate:", err)
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	migrator, err := postgres.NewMigrator(cfg.Postgres.DSN())
+	if err != nil {
+		slog.Error("Failed to open migrator", "err", err)
+		return 1
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			slog.Error("Migration failed", "err", err)
+			return 1
+		}
+		slog.Info("Migrations applied", "count", n)
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			slog.Error("Migration revert failed", "err", err)
+			return 1
+		}
+		slog.Info("Migrations reverted", "count", n)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			slog.Error("Failed to read migration status", "err", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied() {
+				applied = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+// migrateUp applies pending migrations at startup.
+func migrateUp(ctx context.Context, connStr string) error {
+	migrator, err := postgres.NewMigrator(connStr)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	n, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("Schema is up to date", "applied", n)
+	return nil
+}
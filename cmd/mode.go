@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const modeUsage = "usage: marketflow mode [-addr url] get | set live|test"
+
+// runMode implements `marketflow mode`, talking to a running instance's API.
+func runMode(args []string) int {
+	fs := flag.NewFlagSet("marketflow mode", flag.ContinueOnError)
+	addr := fs.String("addr", "http://localhost:8080", "base URL of the running instance")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	args = fs.Args()
+	base := strings.TrimRight(*addr, "/") + "/v1/mode"
+
+	var req *http.Request
+	var err error
+	switch {
+	case len(args) == 1 && args[0] == "get":
+		req, err = http.NewRequest(http.MethodGet, base, nil)
+	case len(args) == 2 && args[0] == "set" && (args[1] == "live" || args[1] == "test"):
+		req, err = http.NewRequest(http.MethodPost, base+"/"+args[1], nil)
+	default:
+		fmt.Fprintln(os.Stderr, modeUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow mode:", err)
+		return 2
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow mode:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "marketflow mode:", err)
+		return 1
+	}
+
+	var out struct {
+		Mode    string `json:"mode"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	_ = json.Unmarshal(body, &out)
+	if resp.StatusCode != http.StatusOK {
+		msg := out.Error
+		if msg == "" {
+			msg = strings.TrimSpace(string(body))
+		}
+		fmt.Fprintf(os.Stderr, "marketflow mode: %s: %s\n", resp.Status, msg)
+		return 1
+	}
+	if out.Mode != "" {
+		fmt.Println(out.Mode)
+	} else {
+		fmt.Println(out.Message)
+	}
+	return 0
+}
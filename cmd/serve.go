@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"marketflow/internal/adapters/websocket"
+	"marketflow/internal/app/aggregator"
+	"marketflow/internal/app/api"
+	"marketflow/internal/app/fanout"
+	"marketflow/internal/app/importer"
+	"marketflow/internal/app/ingest"
+	"marketflow/internal/app/leader"
+	"marketflow/internal/app/mode"
+	"marketflow/internal/app/rollup"
+	"marketflow/internal/app/stream"
+	"marketflow/internal/config"
+	"marketflow/internal/domain"
+	"marketflow/internal/handler"
+	"marketflow/internal/metrics"
+)
+
+// runServe implements `marketflow serve`, returning the exit code.
+func runServe(args []string) int {
+	fs, configPath := newFlagSet("serve")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		slog.Error("Failed to load config", "err", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	registry := cfg.Registry()
+
+	if cfg.Storage.Backend == config.StoragePostgres && cfg.Postgres.Migrate {
+		migrateCtx, cancelMigrate := context.WithTimeout(ctx, time.Minute)
+		err := migrateUp(migrateCtx, cfg.Postgres.DSN())
+		cancelMigrate()
+		if err != nil {
+			slog.Error("Schema migration failed", "err", err)
+			return 1
+		}
+	}
+	store, err := openStorage(cfg)
+	if err != nil {
+		slog.Error("Storage connection error", "backend", cfg.Storage.Backend, "err", err)
+		return 1
+	}
+
+	ticks := openTicks(cfg)
+
+	// The pipeline outlives the signal context so it can be drained in order
+	// on shutdown; cancelling it is the last resort when a step times out.
+	pipelineCtx, stopPipeline := context.WithCancel(context.Background())
+	defer stopPipeline()
+
+	updates := make(chan domain.PriceUpdate, 1000)
+
+	// Every consumer gets its own queue from the hub. Redis storage must not
+	// lose ticks, while the live stream prefers fresh data over old.
+	hub := fanout.NewHub()
+	redisSub := hub.Subscribe("redis", fanout.DefaultQueueSize, fanout.PolicyBlock)
+	streamSub := hub.Subscribe("stream", fanout.DefaultQueueSize, fanout.PolicyDropOldest)
+	go hub.Run(pipelineCtx, updates)
+	metrics.Default.OnCollect(func() {
+		for _, st := range hub.Stats() {
+			metrics.FanoutQueued.Set(float64(st.Queued), st.Name)
+		}
+	})
+
+	broadcaster := stream.NewBroadcaster(stream.DefaultArbitrageThresholdBps)
+	go broadcaster.Run(pipelineCtx, streamSub.C)
+
+	pipeline := ingest.NewPipeline(updates, cfg.Sources())
+	pipeline.Run(pipelineCtx)
+
+	var sources []websocket.Source
+	for _, ex := range cfg.Exchanges {
+		sources = append(sources, websocket.Source{Name: domain.CanonicalExchange(ex.Name), Address: ex.Addr()})
+	}
+	modeManager := mode.NewModeManager(pipeline, registry, sources)
+	initialMode, err := mode.Parse(cfg.Mode)
+	if err != nil {
+		slog.Error("Invalid mode in config", "mode", cfg.Mode, "err", err)
+		return 1
+	}
+	modeManager.SetMode(ctx, initialMode)
+
+	retention, _ := cfg.TickRetention()
+	service := aggregator.NewServiceCom(ticks, store.Save, registry, broadcaster, retention)
+	service.StartRedisWorkerPool(pipelineCtx, redisSub.C, 5)
+	// Only the replica holding the lease aggregates and rolls up; the others
+	// serve the API.
+	leaseTTL, _ := cfg.LeaseTTL()
+	elector := leader.NewElector(ticks, leader.DefaultKey, leaseTTL)
+	resolutions, _ := cfg.Resolutions()
+	rollups := rollup.NewJob(store.Rollups, resolutions)
+	aggCtx, stopAggregator := context.WithCancel(pipelineCtx)
+	aggDone := make(chan struct{})
+	go func() {
+		defer close(aggDone)
+		elector.Run(aggCtx, func(ctx context.Context) {
+			rolledUp := make(chan struct{})
+			go func() {
+				defer close(rolledUp)
+				rollups.Run(ctx)
+			}()
+			service.StartAggregator(ctx)
+			<-rolledUp
+		})
+	}()
+
+	apiService := api.NewService(store.API, ticks, registry, resolutions)
+	timeouts, _ := cfg.Timeouts()
+	apiHandler := handler.NewHandler(apiService, modeManager, broadcaster, timeouts)
+	apiHandler.Importer = importer.NewImporter(store.Save, rollups, registry)
+	apiHandler.AdminToken = cfg.Admin.Token
+	apiHandler.AllowedOrigins = cfg.WebSocket.AllowedOrigins
+
+	healthHandler := &handler.HealthHandler{
+		DB:       store.API,
+		Storage:  cfg.Storage.Backend,
+		Ticks:    cfg.Storage.Ticks,
+		Redis:    ticks,
+		Pipeline: hub,
+		Ingest:   pipeline,
+		Leader:   elector,
+	}
+	router := handler.NewRouter(apiHandler, healthHandler)
+
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: handler.RequestID(handler.AccessLog(router)),
+	}
+	// Live streams never go idle, so end them when shutdown begins.
+	server.RegisterOnShutdown(broadcaster.Close)
+
+	go func() {
+		slog.Info("Starting HTTP server", "address", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("HTTP server failed", "err", err)
+			cancel()
+		}
+	}()
+
+	slog.Info("Service is running")
+
+	<-ctx.Done()
+	slog.Info("Shutdown signal received")
+
+	shutdownStep("http server", 10*time.Second, server.Shutdown)
+	shutdownStep("sources", 5*time.Second, modeManager.Stop)
+	if !shutdownStep("ingest pipeline", 10*time.Second, pipeline.Close) {
+		stopPipeline()
+	}
+	if !shutdownStep("redis workers", 10*time.Second, service.WaitWorkers) {
+		stopPipeline()
+	}
+	wasLeader := elector.IsLeader()
+	shutdownStep("aggregator", 5*time.Second, func(ctx context.Context) error {
+		stopAggregator()
+		select {
+		case <-aggDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if wasLeader {
+		shutdownStep("final aggregation", 15*time.Second, service.Flush)
+	}
+	stopPipeline()
+
+	shutdownStep("adapters", 5*time.Second, func(context.Context) error {
+		return errors.Join(store.Close(), ticks.Close())
+	})
+
+	slog.Info("Application shutdown complete")
+	return 0
+}
+
+// shutdownStep runs one shutdown step with its own timeout and reports
+// whether it completed in time.
+func shutdownStep(name string, timeout time.Duration, step func(context.Context) error) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := step(ctx); err != nil {
+		slog.Error("Shutdown step failed", "step", name, "timeout", timeout, "err", err)
+		return false
+	}
+	slog.Info("Shutdown step complete", "step", name, "duration", time.Since(start))
+	return true
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/adapters/postgres"
+	"marketflow/internal/adapters/redis"
+	"marketflow/internal/app"
+	"marketflow/internal/config"
+)
+
+// aggregateStore is the aggregate storage selected by storage.backend: the
+// write side used by the aggregator, rollups and imports, and the read side
+// used by the API.
+type aggregateStore struct {
+	Save    app.SavePGRepo
+	Rollups app.RollupRepo
+	API     app.APIRepo
+	close   func() error
+}
+
+func (s *aggregateStore) Close() error {
+	return s.close()
+}
+
+// openStorage opens the configured backend. PostgreSQL gets separate pools for
+// writes and API reads, so slow queries cannot hold up aggregation.
+func openStorage(cfg *config.Config) (*aggregateStore, error) {
+	if cfg.Storage.Backend == config.StorageMemory {
+		store := memory.NewStore()
+		return &aggregateStore{Save: store, Rollups: store, API: store, close: store.Close}, nil
+	}
+
+	connStr := cfg.Postgres.DSN()
+	pgAdapter, err := postgres.NewPostgresAdapter(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	apiAdapter, err := postgres.NewApiAdapter(connStr)
+	if err != nil {
+		pgAdapter.Close()
+		return nil, fmt.Errorf("postgres api adapter: %w", err)
+	}
+	return &aggregateStore{
+		Save:    pgAdapter,
+		Rollups: pgAdapter,
+		API:     apiAdapter,
+		close: func() error {
+			return errors.Join(pgAdapter.Close(), apiAdapter.Close())
+		},
+	}, nil
+}
+
+// tickStore is the raw tick store selected by storage.ticks, which also holds
+// the leader lease.
+type tickStore interface {
+	app.RedisRepo
+	app.LeaseRepo
+	Close() error
+}
+
+func openTicks(cfg *config.Config) tickStore {
+	if cfg.Storage.Ticks == config.StorageMemory {
+		return memory.NewSortedSets()
+	}
+	return redis.NewRedisAdapter(cfg.Redis.Addr(), cfg.Redis.Password, cfg.Redis.DB)
+}
+
+// requirePostgres rejects commands that work on the stored aggregates of a
+// running service when they would only see a private in-memory store.
+func requirePostgres(cfg *config.Config, command string) error {
+	if cfg.Storage.Backend != config.StoragePostgres {
+		return fmt.Errorf("%s needs storage.backend postgres; the %s backend lives inside the serving process", command, cfg.Storage.Backend)
+	}
+	return nil
+}
+
+// requireRedis is requirePostgres for commands reading the raw ticks.
+func requireRedis(cfg *config.Config, command string) error {
+	if cfg.Storage.Ticks != config.StorageRedis {
+		return fmt.Errorf("%s needs storage.ticks redis; the %s tick store lives inside the serving process", command, cfg.Storage.Ticks)
+	}
+	return nil
+}
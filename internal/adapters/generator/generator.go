@@ -4,21 +4,32 @@ import (
 	"context"
 	"log/slog"
 	"math/rand"
+	"sync"
 	"time"
 
 	"marketflow/internal/domain"
 )
 
-var testPairs = []string{"BTCUSDT", "ETHUSDT", "DOGEUSDT", "TONUSDT", "SOLUSDT"}
+// Sink accepts generated ticks and applies the source's backpressure policy.
+type Sink interface {
+	Send(ctx context.Context, update domain.PriceUpdate) bool
+}
 
-func StartTestGenerators(ctx context.Context, out chan<- domain.PriceUpdate) {
-	exchanges := []string{"Exchange1", "Exchange2", "Exchange3"}
+// StartTestGenerators runs one generator per exchange and returns once ctx
+// is done and every generator has stopped.
+func StartTestGenerators(ctx context.Context, exchanges, pairs []string, sinkFor func(exchange string) Sink) {
+	var wg sync.WaitGroup
 	for _, exchange := range exchanges {
-		go generateForExchange(ctx, exchange, out)
+		wg.Add(1)
+		go func(exchange string) {
+			defer wg.Done()
+			generateForExchange(ctx, exchange, pairs, sinkFor(exchange))
+		}(exchange)
 	}
+	wg.Wait()
 }
 
-func generateForExchange(ctx context.Context, exchange string, out chan<- domain.PriceUpdate) {
+func generateForExchange(ctx context.Context, exchange string, pairs []string, sink Sink) {
 	slog.Info("Test generator started", "exchange", exchange)
 
 	ticker := time.NewTicker(1 * time.Second)
@@ -31,7 +42,7 @@ func generateForExchange(ctx context.Context, exchange string, out chan<- domain
 			return
 
 		case t := <-ticker.C:
-			for _, pair := range testPairs {
+			for _, pair := range pairs {
 				price := rand.Float64()*100 + 1
 
 				update := domain.PriceUpdate{
@@ -41,12 +52,11 @@ func generateForExchange(ctx context.Context, exchange string, out chan<- domain
 					Exchange:  exchange,
 				}
 
-				select {
-				case out <- update:
-					slog.Debug("Generated test price", "exchange", exchange, "pair", pair, "price", price)
-				default:
-					slog.Warn("Output channel full, price dropped", "exchange", exchange, "pair", pair)
+				if !sink.Send(ctx, update) {
+					slog.Warn("Test generator stopped", "exchange", exchange)
+					return
 				}
+				slog.Debug("Generated test price", "exchange", exchange, "pair", pair, "price", price)
 			}
 		}
 	}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// exportChunk is how many rows StreamAggregates copies out per lock, so a
+// long export does not hold up writers.
+const exportChunk = 500
+
+// ref is a row copied out of its series.
+type ref struct {
+	pair, exchange string
+	row
+}
+
+// collect copies up to perSeries rows of each selected series that start in
+// rng at or after since, ordered by window start and exchange.
+func (s *Store) collect(pair, exchange string, rng domain.TimeRange, since time.Time, perSeries int) []ref {
+	from := rng.From
+	if since.After(from) {
+		from = since
+	}
+
+	s.mu.RLock()
+	var refs []ref
+	for _, key := range s.match(pair, exchange, rng) {
+		rows := s.series[key].within(from, rng.To)
+		if len(rows) > perSeries {
+			rows = rows[:perSeries]
+		}
+		for _, r := range rows {
+			refs = append(refs, ref{pair: key.pair, exchange: key.exchange, row: r})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(refs, func(i, j int) bool {
+		if !refs[i].start.Equal(refs[j].start) {
+			return refs[i].start.Before(refs[j].start)
+		}
+		return refs[i].exchange < refs[j].exchange
+	})
+	return refs
+}
+
+func (s *Store) GetHistory(ctx context.Context, q domain.HistoryQuery) ([]domain.HistoryRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// A series has at most one row at the cursor's window start, so one
+	// extra row per series is enough to fill the page after skipping it.
+	refs := s.collect(q.Symbol, q.Exchange, q.Range, q.AfterTS, q.Limit+1)
+	sort.SliceStable(refs, func(i, j int) bool {
+		if !refs[i].start.Equal(refs[j].start) {
+			return refs[i].start.Before(refs[j].start)
+		}
+		return refs[i].id < refs[j].id
+	})
+
+	var records []domain.HistoryRecord
+	for _, r := range refs {
+		if len(records) == q.Limit {
+			break
+		}
+		if !q.AfterTS.IsZero() && r.start.Equal(q.AfterTS) && r.id <= q.AfterID {
+			continue
+		}
+		records = append(records, domain.HistoryRecord{
+			ID:        r.id,
+			Timestamp: r.start,
+			Price:     *r.response(r.pair, r.exchange),
+		})
+	}
+	return records, nil
+}
+
+func (s *Store) GetCoverage(ctx context.Context) ([]domain.Coverage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	var coverage []domain.Coverage
+	for key, ser := range s.series {
+		if key.interval != time.Minute || len(ser.rows) == 0 {
+			continue
+		}
+		coverage = append(coverage, domain.Coverage{
+			Exchange:       key.exchange,
+			Symbol:         key.pair,
+			FirstAggregate: ser.rows[0].start.Format(time.RFC3339),
+			LastAggregate:  ser.rows[len(ser.rows)-1].start.Format(time.RFC3339),
+			Rows:           int64(len(ser.rows)),
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(coverage, func(i, j int) bool {
+		if coverage[i].Exchange != coverage[j].Exchange {
+			return coverage[i].Exchange < coverage[j].Exchange
+		}
+		return coverage[i].Symbol < coverage[j].Symbol
+	})
+	return coverage, nil
+}
+
+// StreamAggregates calls fn for each selected row in time order, copying a
+// chunk of rows at a time so fn runs without the lock held.
+func (s *Store) StreamAggregates(ctx context.Context, q domain.ExportQuery, fn func(domain.AggregatedResponse) error) error {
+	var (
+		sent     int
+		after    time.Time
+		afterEx  string
+		resuming bool
+	)
+	for sent < q.Limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// As in GetHistory, one extra row per series covers the rows at the
+		// cursor's window start that were already sent.
+		chunk := 0
+		for _, r := range s.collect(q.Symbol, q.Exchange, q.Range, after, exportChunk+1) {
+			if resuming && r.start.Equal(after) && r.exchange <= afterEx {
+				continue
+			}
+			if chunk == exportChunk || sent == q.Limit {
+				break
+			}
+			if err := fn(*r.response(r.pair, r.exchange)); err != nil {
+				return err
+			}
+			chunk++
+			sent++
+			after, afterEx, resuming = r.start, r.exchange, true
+		}
+		if chunk < exportChunk {
+			return nil
+		}
+	}
+	return nil
+}
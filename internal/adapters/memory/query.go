@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// Comparators choosing the row that answers a statistic: the newest, or the
+// one with the highest maximum or lowest minimum price.
+var (
+	latestRow  = func(a, b row) bool { return a.start.After(b.start) }
+	highestRow = func(a, b row) bool { return a.max > b.max }
+	lowestRow  = func(a, b row) bool { return a.min < b.min }
+)
+
+func (s *Store) GetPriceForSymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, "", domain.TimeRange{}, latestRow)
+}
+
+func (s *Store) GetPriceForExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, exchange, domain.TimeRange{}, latestRow)
+}
+
+func (s *Store) QueryLatestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, "", rng, latestRow)
+}
+
+func (s *Store) QueryLatestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, exchange, rng, latestRow)
+}
+
+func (s *Store) QueryHighestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, "", rng, highestRow)
+}
+
+func (s *Store) QueryHighestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, exchange, rng, highestRow)
+}
+
+func (s *Store) QueryLowestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, "", rng, lowestRow)
+}
+
+func (s *Store) QueryLowestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.pick(ctx, symbol, exchange, rng, lowestRow)
+}
+
+func (s *Store) QueryAvgInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.average(ctx, symbol, "", rng)
+}
+
+func (s *Store) QueryAvgInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return s.average(ctx, symbol, exchange, rng)
+}
+
+// pick returns the row of the selected series for which better holds against
+// every other, or nil when the range holds no rows.
+func (s *Store) pick(ctx context.Context, pair, exchange string, rng domain.TimeRange, better func(a, b row) bool) (*domain.AggregatedResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pickLocked(pair, exchange, rng, better), nil
+}
+
+func (s *Store) pickLocked(pair, exchange string, rng domain.TimeRange, better func(a, b row) bool) *domain.AggregatedResponse {
+	var (
+		best   row
+		bestEx string
+		found  bool
+	)
+	for _, key := range s.match(pair, exchange, rng) {
+		for _, r := range s.series[key].within(rng.From, rng.To) {
+			if !found || better(r, best) {
+				best, bestEx, found = r, key.exchange, true
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	return best.response(pair, bestEx)
+}
+
+// average returns the mean of the selected averages weighted by the minutes
+// behind each, stamped like the PostgreSQL adapter with the end of the range.
+func (s *Store) average(ctx context.Context, pair, exchange string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.averageLocked(pair, exchange, rng), nil
+}
+
+func (s *Store) averageLocked(pair, exchange string, rng domain.TimeRange) *domain.AggregatedResponse {
+	var sum float64
+	var n int
+	for _, key := range s.match(pair, exchange, rng) {
+		for _, r := range s.series[key].within(rng.From, rng.To) {
+			sum += r.avg * float64(r.minutes)
+			n += r.minutes
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return &domain.AggregatedResponse{
+		Pair:      pair,
+		Exchange:  exchange,
+		Timestamp: rangeTimestamp(rng),
+		Avg:       sum / float64(n),
+	}
+}
+
+// QueryBatch answers one statistic for every key; an empty exchange matches
+// every exchange. Keys without data are left out of the result.
+func (s *Store) QueryBatch(ctx context.Context, stat string, rng domain.TimeRange, keys []domain.SeriesKey) (map[domain.SeriesKey]*domain.AggregatedResponse, error) {
+	var better func(a, b row) bool
+	switch stat {
+	case domain.StatLatest:
+		better = latestRow
+	case domain.StatHighest:
+		better = highestRow
+	case domain.StatLowest:
+		better = lowestRow
+	case domain.StatAverage:
+	default:
+		return nil, errors.New("unsupported statistic: " + stat)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make(map[domain.SeriesKey]*domain.AggregatedResponse, len(keys))
+	for _, key := range keys {
+		var resp *domain.AggregatedResponse
+		if better == nil {
+			resp = s.averageLocked(key.Symbol, key.Exchange, rng)
+		} else {
+			resp = s.pickLocked(key.Symbol, key.Exchange, rng, better)
+		}
+		if resp != nil {
+			results[key] = resp
+		}
+	}
+	return results, nil
+}
+
+func rangeTimestamp(rng domain.TimeRange) string {
+	if rng.To.IsZero() {
+		return time.Now().Format(time.RFC3339)
+	}
+	return rng.To.Format(time.RFC3339)
+}
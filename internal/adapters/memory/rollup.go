@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// Rollup writes target-interval aggregates for every bucket starting in
+// [from, to) from the source-interval rows it contains: the average of their
+// averages weighted by the minutes behind each, the lowest minimum and the
+// highest maximum. Buckets are aligned to UTC midnight, and rewriting a
+// bucket replaces its values.
+func (s *Store) Rollup(ctx context.Context, source, target time.Duration, from, to time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	type bucket struct {
+		sum, min, max float64
+		n             int
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var written int64
+	for key, ser := range s.series {
+		if key.interval != source {
+			continue
+		}
+		buckets := make(map[time.Time]*bucket)
+		var order []time.Time
+		for _, r := range ser.within(from, to) {
+			start := r.start.Truncate(target)
+			b := buckets[start]
+			if b == nil {
+				b = &bucket{min: r.min, max: r.max}
+				buckets[start] = b
+				order = append(order, start)
+			}
+			b.sum += r.avg * float64(r.minutes)
+			b.n += r.minutes
+			if r.min < b.min {
+				b.min = r.min
+			}
+			if r.max > b.max {
+				b.max = r.max
+			}
+		}
+		targetKey := seriesKey{key.pair, key.exchange, target}
+		for _, start := range order {
+			b := buckets[start]
+			s.upsert(targetKey, start, b.sum/float64(b.n), b.min, b.max, b.n)
+			written++
+		}
+	}
+	return written, nil
+}
+
+// PruneAggregates deletes rows of the given interval that start before before.
+func (s *Store) PruneAggregates(ctx context.Context, interval time.Duration, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pruned int64
+	for key, ser := range s.series {
+		if key.interval != interval {
+			continue
+		}
+		n := ser.search(before)
+		if n == 0 {
+			continue
+		}
+		pruned += int64(n)
+		ser.rows = append([]row(nil), ser.rows[n:]...)
+		if len(ser.rows) == 0 {
+			delete(s.series, key)
+		}
+	}
+	return pruned, nil
+}
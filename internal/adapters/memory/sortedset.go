@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SortedSets stands in for Redis: it implements the sorted-set commands of
+// app.RedisRepo and the lease of app.LeaseRepo in process memory, with Redis
+// semantics. Members are unique per key and ordered by score, then member;
+// score bounds are inclusive; a key disappears with its last member.
+type SortedSets struct {
+	mu     sync.Mutex
+	sets   map[string]*sortedSet
+	leases map[string]lease
+	now    func() time.Time
+}
+
+type sortedSet struct {
+	scores  map[string]int64
+	entries []entry
+}
+
+type entry struct {
+	score  int64
+	member string
+}
+
+func (e entry) less(o entry) bool {
+	if e.score != o.score {
+		return e.score < o.score
+	}
+	return e.member < o.member
+}
+
+type lease struct {
+	owner   string
+	expires time.Time
+}
+
+func NewSortedSets() *SortedSets {
+	slog.Info("Using in-memory tick storage; ticks are lost on exit and not shared between replicas")
+	return &SortedSets{
+		sets:   make(map[string]*sortedSet),
+		leases: make(map[string]lease),
+		now:    time.Now,
+	}
+}
+
+func (s *SortedSets) Close() error {
+	return nil
+}
+
+func (s *SortedSets) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// ZAdd adds value with score, or moves an existing value to the new score.
+func (s *SortedSets) ZAdd(ctx context.Context, key string, score int64, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := s.sets[key]
+	if set == nil {
+		set = &sortedSet{scores: make(map[string]int64)}
+		s.sets[key] = set
+	}
+	if old, ok := set.scores[value]; ok {
+		if old == score {
+			return nil
+		}
+		i := set.search(entry{old, value})
+		set.entries = append(set.entries[:i], set.entries[i+1:]...)
+	}
+	set.scores[value] = score
+	e := entry{score, value}
+	i := set.search(e)
+	set.entries = append(set.entries, entry{})
+	copy(set.entries[i+1:], set.entries[i:])
+	set.entries[i] = e
+	return nil
+}
+
+// ZRangeByScore returns the members scored in [min, max] in order.
+func (s *SortedSets) ZRangeByScore(ctx context.Context, key string, min, max int64) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := s.sets[key]
+	if set == nil {
+		return []string{}, nil
+	}
+	lo, hi := set.scoreRange(min, max)
+	members := make([]string, 0, hi-lo)
+	for _, e := range set.entries[lo:hi] {
+		members = append(members, e.member)
+	}
+	return members, nil
+}
+
+// ZRemRangeByScore removes the members scored in [min, max].
+func (s *SortedSets) ZRemRangeByScore(ctx context.Context, key string, min, max int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := s.sets[key]
+	if set == nil {
+		return nil
+	}
+	lo, hi := set.scoreRange(min, max)
+	for _, e := range set.entries[lo:hi] {
+		delete(set.scores, e.member)
+	}
+	set.entries = append(set.entries[:lo], set.entries[hi:]...)
+	if len(set.entries) == 0 {
+		delete(s.sets, key)
+	}
+	return nil
+}
+
+// ZMaxScore returns the highest score in the sorted set, reporting false when
+// the set is empty or missing.
+func (s *SortedSets) ZMaxScore(ctx context.Context, key string) (int64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := s.sets[key]
+	if set == nil {
+		return 0, false, nil
+	}
+	return set.entries[len(set.entries)-1].score, true, nil
+}
+
+// search returns the position of e, or where it would be inserted.
+func (set *sortedSet) search(e entry) int {
+	return sort.Search(len(set.entries), func(i int) bool { return !set.entries[i].less(e) })
+}
+
+// scoreRange returns the bounds of the entries scored in [min, max].
+func (set *sortedSet) scoreRange(min, max int64) (int, int) {
+	lo := sort.Search(len(set.entries), func(i int) bool { return set.entries[i].score >= min })
+	hi := sort.Search(len(set.entries), func(i int) bool { return set.entries[i].score > max })
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// AcquireLease takes the lease at key if it is free or expired and extends it
+// if owner already holds it, reporting whether owner holds it for the next
+// ttl.
+func (s *SortedSets) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if l, ok := s.leases[key]; ok && l.owner != owner && now.Before(l.expires) {
+		return false, nil
+	}
+	s.leases[key] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease gives up the lease at key if owner holds it.
+func (s *SortedSets) ReleaseLease(ctx context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.leases[key]; ok && l.owner == owner {
+		delete(s.leases, key)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func zrange(t *testing.T, s *SortedSets, key string, min, max int64) []string {
+	t.Helper()
+	members, err := s.ZRangeByScore(context.Background(), key, min, max)
+	if err != nil {
+		t.Fatalf("ZRangeByScore: %v", err)
+	}
+	return members
+}
+
+func TestSortedSetOrderingAndBounds(t *testing.T) {
+	s := NewSortedSets()
+	ctx := context.Background()
+	for _, e := range []entry{{30, "c"}, {10, "b"}, {20, "x"}, {10, "a"}, {40, "d"}} {
+		if err := s.ZAdd(ctx, "k", e.score, e.member); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		min, max int64
+		want     []string
+	}{
+		{"all, ties by member", 0, 100, []string{"a", "b", "x", "c", "d"}},
+		{"inclusive bounds", 10, 30, []string{"a", "b", "x", "c"}},
+		{"single score", 20, 20, []string{"x"}},
+		{"between scores", 11, 19, []string{}},
+		{"inverted", 30, 10, []string{}},
+	}
+	for _, tt := range tests {
+		if got := zrange(t, s, "k", tt.min, tt.max); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ZRangeByScore(%d, %d) = %q, want %q", tt.name, tt.min, tt.max, got, tt.want)
+		}
+	}
+	if got := zrange(t, s, "missing", 0, 100); got == nil || len(got) != 0 {
+		t.Errorf("missing key = %#v, want an empty slice", got)
+	}
+
+	// Re-adding a member moves it rather than duplicating it.
+	s.ZAdd(ctx, "k", 50, "a")
+	s.ZAdd(ctx, "k", 10, "b")
+	if got, want := zrange(t, s, "k", 0, 100), []string{"b", "x", "c", "d", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after re-adding = %q, want %q", got, want)
+	}
+	if max, ok, _ := s.ZMaxScore(ctx, "k"); !ok || max != 50 {
+		t.Errorf("ZMaxScore = %d, %v; want 50", max, ok)
+	}
+}
+
+func TestZRemRangeByScore(t *testing.T) {
+	s := NewSortedSets()
+	ctx := context.Background()
+	for i, m := range []string{"a", "b", "c", "d"} {
+		s.ZAdd(ctx, "k", int64(i+1)*10, m)
+	}
+
+	if err := s.ZRemRangeByScore(ctx, "k", 0, 20); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := zrange(t, s, "k", 0, 100), []string{"c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after removing [0, 20] = %q, want %q", got, want)
+	}
+	// A removed member can be added again at its old score.
+	s.ZAdd(ctx, "k", 10, "a")
+	if got, want := zrange(t, s, "k", 0, 100), []string{"a", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after re-adding = %q, want %q", got, want)
+	}
+
+	s.ZRemRangeByScore(ctx, "k", 0, 40)
+	if _, ok, _ := s.ZMaxScore(ctx, "k"); ok {
+		t.Error("ZMaxScore reports a score for an emptied set")
+	}
+	if len(s.sets) != 0 {
+		t.Errorf("emptied key still stored: %v", s.sets)
+	}
+	if err := s.ZRemRangeByScore(ctx, "missing", 0, 1); err != nil {
+		t.Errorf("ZRemRangeByScore on a missing key: %v", err)
+	}
+}
+
+// TestLease checks the in-memory lease against the behaviour of the Redis
+// acquire and release scripts: SET NX PX to take, PEXPIRE to renew, and a
+// compare-and-delete to release.
+func TestLease(t *testing.T) {
+	s := NewSortedSets()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	s.now = func() time.Time { return now }
+	const ttl = 15 * time.Second
+
+	acquire := func(owner string, want bool) {
+		t.Helper()
+		got, err := s.AcquireLease(ctx, "leader", owner, ttl)
+		if err != nil || got != want {
+			t.Fatalf("at +%v AcquireLease(%s) = %v, %v; want %v", now.Sub(start), owner, got, err, want)
+		}
+	}
+
+	acquire("a", true)
+	acquire("b", false)
+
+	// Renewing pushes the expiry to ttl from the renewal.
+	now = now.Add(10 * time.Second)
+	acquire("a", true)
+	now = now.Add(10 * time.Second)
+	acquire("b", false)
+
+	// Once the lease lapses anyone may take it, and the old owner cannot
+	// renew it any more.
+	now = now.Add(ttl)
+	acquire("b", true)
+	acquire("a", false)
+
+	// Only the holder can release it.
+	s.ReleaseLease(ctx, "leader", "a")
+	acquire("a", false)
+	s.ReleaseLease(ctx, "leader", "b")
+	acquire("a", true)
+
+	// Leases at other keys are independent.
+	if ok, _ := s.AcquireLease(ctx, "other", "b", ttl); !ok {
+		t.Error("lease at another key is not free")
+	}
+}
@@ -0,0 +1,135 @@
+// Package memory keeps aggregates and raw ticks in process memory. It stands
+// in for PostgreSQL and Redis when running locally as a single binary or in
+// tests; nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+// Store implements the aggregate repositories (app.APIRepo, app.SavePGRepo
+// and app.RollupRepo) with the same semantics as the PostgreSQL adapters:
+// one row per pair, exchange, window start and interval, upserted on write.
+type Store struct {
+	mu     sync.RWMutex
+	series map[seriesKey]*series
+	nextID int64
+}
+
+type seriesKey struct {
+	pair, exchange string
+	interval       time.Duration
+}
+
+// series holds the rows of one pair, exchange and interval ordered by window
+// start.
+type series struct {
+	rows []row
+}
+
+// minutes is how many minute rows a row stands for, weighting it in averages.
+type row struct {
+	id            int64
+	start         time.Time
+	avg, min, max float64
+	minutes       int
+}
+
+func NewStore() *Store {
+	slog.Info("Using in-memory aggregate storage; data is lost on exit")
+	return &Store{series: make(map[seriesKey]*series)}
+}
+
+func (s *Store) Close() error {
+	return nil
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// SaveAggregatedPrice writes the one-minute aggregate for the window starting
+// at ts, replacing the values of an existing row.
+func (s *Store) SaveAggregatedPrice(ctx context.Context, pair, exchange string, ts time.Time, avg, min, max float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.upsert(seriesKey{pair, exchange, time.Minute}, ts, avg, min, max, 1)
+	return nil
+}
+
+// upsert must be called with mu held for writing.
+func (s *Store) upsert(key seriesKey, start time.Time, avg, min, max float64, minutes int) {
+	start = start.UTC()
+	ser := s.series[key]
+	if ser == nil {
+		ser = &series{}
+		s.series[key] = ser
+	}
+	i := ser.search(start)
+	if i < len(ser.rows) && ser.rows[i].start.Equal(start) {
+		r := &ser.rows[i]
+		r.avg, r.min, r.max, r.minutes = avg, min, max, minutes
+		return
+	}
+	s.nextID++
+	ser.rows = append(ser.rows, row{})
+	copy(ser.rows[i+1:], ser.rows[i:])
+	ser.rows[i] = row{id: s.nextID, start: start, avg: avg, min: min, max: max, minutes: minutes}
+}
+
+// search returns the index of the first row starting at or after t.
+func (ser *series) search(t time.Time) int {
+	return sort.Search(len(ser.rows), func(i int) bool { return !ser.rows[i].start.Before(t) })
+}
+
+// within returns the rows starting in [from, to); zero bounds are open.
+func (ser *series) within(from, to time.Time) []row {
+	lo, hi := 0, len(ser.rows)
+	if !from.IsZero() {
+		lo = ser.search(from)
+	}
+	if !to.IsZero() {
+		hi = ser.search(to)
+	}
+	if lo >= hi {
+		return nil
+	}
+	return ser.rows[lo:hi]
+}
+
+// match reports the series of pair at the resolution of rng, on exchange or
+// on every exchange when it is empty, in exchange order. mu must be held.
+func (s *Store) match(pair, exchange string, rng domain.TimeRange) []seriesKey {
+	interval := rng.Resolution
+	if interval == 0 {
+		interval = time.Minute
+	}
+	var keys []seriesKey
+	for key := range s.series {
+		if key.pair == pair && key.interval == interval && (exchange == "" || key.exchange == exchange) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].exchange < keys[j].exchange })
+	return keys
+}
+
+func (r row) response(pair, exchange string) *domain.AggregatedResponse {
+	return &domain.AggregatedResponse{
+		Pair:      pair,
+		Exchange:  exchange,
+		Timestamp: r.start.Format(time.RFC3339),
+		Avg:       r.avg,
+		Min:       r.min,
+		Max:       r.max,
+	}
+}
@@ -0,0 +1,248 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+var t0 = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func minute(i int) time.Time { return t0.Add(time.Duration(i) * time.Minute) }
+
+func mustSave(t *testing.T, s *Store, pair, exchange string, ts time.Time, avg, min, max float64) {
+	t.Helper()
+	if err := s.SaveAggregatedPrice(context.Background(), pair, exchange, ts, avg, min, max); err != nil {
+		t.Fatalf("SaveAggregatedPrice: %v", err)
+	}
+}
+
+func history(t *testing.T, s *Store, q domain.HistoryQuery) []domain.HistoryRecord {
+	t.Helper()
+	records, err := s.GetHistory(context.Background(), q)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	return records
+}
+
+func TestSaveUpsertsOnSeriesAndWindow(t *testing.T) {
+	s := NewStore()
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(0), 10, 9, 11)
+	// The same instant in another zone is the same window.
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(0).In(time.FixedZone("UTC+3", 3*3600)), 20, 19, 21)
+	mustSave(t, s, "BTCUSDT", "exchange2", minute(0), 30, 29, 31)
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(1), 40, 39, 41)
+	mustSave(t, s, "ETHUSDT", "exchange1", minute(0), 50, 49, 51)
+
+	records := history(t, s, domain.HistoryQuery{Symbol: "BTCUSDT", Exchange: "exchange1", Limit: 10})
+	if len(records) != 2 {
+		t.Fatalf("got %d rows, want 2", len(records))
+	}
+	if p := records[0].Price; p.Avg != 20 || p.Min != 19 || p.Max != 21 || p.Timestamp != "2024-03-01T00:00:00Z" {
+		t.Errorf("upserted row = %+v, want the second write stamped in UTC", p)
+	}
+
+	coverage, _ := s.GetCoverage(context.Background())
+	rows := map[string]int64{}
+	for _, c := range coverage {
+		rows[c.Symbol+"/"+c.Exchange] = c.Rows
+	}
+	want := map[string]int64{"BTCUSDT/exchange1": 2, "BTCUSDT/exchange2": 1, "ETHUSDT/exchange1": 1}
+	if len(rows) != len(want) {
+		t.Errorf("coverage = %v, want %v", rows, want)
+	}
+	for k, n := range want {
+		if rows[k] != n {
+			t.Errorf("coverage %s = %d rows, want %d", k, rows[k], n)
+		}
+	}
+}
+
+func TestHistoryKeysetPaging(t *testing.T) {
+	s := NewStore()
+	// Rows are written out of order so ids do not follow window starts.
+	for _, i := range []int{4, 0, 3, 1, 2, 5} {
+		mustSave(t, s, "BTCUSDT", "exchange2", minute(i), float64(i), 0, 0)
+		mustSave(t, s, "BTCUSDT", "exchange1", minute(i), float64(i), 0, 0)
+	}
+	rng := domain.TimeRange{From: minute(1), To: minute(5)}
+
+	var got []domain.HistoryRecord
+	q := domain.HistoryQuery{Symbol: "BTCUSDT", Range: rng, Limit: 3}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("paging does not terminate")
+		}
+		page := history(t, s, q)
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, page...)
+		last := page[len(page)-1]
+		q.AfterTS, q.AfterID = last.Timestamp, last.ID
+	}
+
+	// Minutes 1..4 on two exchanges: [From, To) excludes minute 5.
+	if len(got) != 8 {
+		t.Fatalf("paged %d rows, want 8", len(got))
+	}
+	seen := map[int64]bool{}
+	for i, r := range got {
+		if seen[r.ID] {
+			t.Errorf("row %d returned twice", r.ID)
+		}
+		seen[r.ID] = true
+		if want := minute(1 + i/2); !r.Timestamp.Equal(want) {
+			t.Errorf("row %d starts at %v, want %v", i, r.Timestamp, want)
+		}
+		if i > 0 && r.Timestamp.Equal(got[i-1].Timestamp) && r.ID < got[i-1].ID {
+			t.Errorf("rows %d and %d are not ordered by id within their window", i-1, i)
+		}
+	}
+}
+
+func TestRollupWeightsAverages(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	// Minutes 0..9: averages 0..9, min avg-1, max avg+1; minute 7 is the
+	// highest maximum and minute 2 the lowest minimum.
+	for i := 0; i < 10; i++ {
+		min, max := float64(i)-1, float64(i)+1
+		if i == 7 {
+			max = 100
+		}
+		if i == 2 {
+			min = -50
+		}
+		mustSave(t, s, "BTCUSDT", "exchange1", minute(i), float64(i), min, max)
+	}
+
+	n, err := s.Rollup(ctx, time.Minute, 5*time.Minute, t0, minute(10))
+	if err != nil || n != 2 {
+		t.Fatalf("Rollup 1m->5m = %d, %v; want 2 buckets", n, err)
+	}
+	// Only the first 5m bucket feeds the hour, so its average must count
+	// five minutes, not one row.
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(10), 100, 100, 100)
+	if _, err := s.Rollup(ctx, time.Minute, 5*time.Minute, minute(10), minute(15)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rollup(ctx, 5*time.Minute, time.Hour, t0, t0.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	five := domain.TimeRange{From: t0, To: minute(10), Resolution: 5 * time.Minute}
+	hour := domain.TimeRange{From: t0, To: t0.Add(time.Hour), Resolution: time.Hour}
+	avg, _ := s.QueryAvgInRangeByExchange(ctx, "exchange1", "BTCUSDT", five)
+	if avg == nil || avg.Avg != 4.5 {
+		t.Errorf("5m average = %+v, want 4.5", avg)
+	}
+	avg, _ = s.QueryAvgInRangeByExchange(ctx, "exchange1", "BTCUSDT", hour)
+	if want := (45.0 + 100) / 11; avg == nil || math.Abs(avg.Avg-want) > 1e-9 {
+		t.Errorf("1h average = %+v, want %v", avg, want)
+	}
+
+	high, _ := s.QueryHighestInRange(ctx, "BTCUSDT", five)
+	if high == nil || high.Max != 100 || high.Timestamp != minute(5).Format(time.RFC3339) {
+		t.Errorf("5m highest = %+v, want max 100 in the bucket at minute 5", high)
+	}
+	low, _ := s.QueryLowestInRange(ctx, "BTCUSDT", five)
+	if low == nil || low.Min != -50 || low.Timestamp != t0.Format(time.RFC3339) {
+		t.Errorf("5m lowest = %+v, want min -50 in the bucket at minute 0", low)
+	}
+
+	// Rolling up again after a correction replaces the bucket.
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(0), 5, -1, 1)
+	if _, err := s.Rollup(ctx, time.Minute, 5*time.Minute, t0, minute(5)); err != nil {
+		t.Fatal(err)
+	}
+	records := history(t, s, domain.HistoryQuery{Symbol: "BTCUSDT", Range: five, Limit: 10})
+	if len(records) != 2 || records[0].Price.Avg != 3 {
+		t.Errorf("5m rows after correction = %+v, want 2 with the first averaging 3", records)
+	}
+
+	pruned, _ := s.PruneAggregates(ctx, time.Minute, minute(5))
+	if pruned != 5 {
+		t.Errorf("pruned %d minute rows, want 5", pruned)
+	}
+}
+
+func TestQueryBatchPerKey(t *testing.T) {
+	s := NewStore()
+	ctx := context.Background()
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(0), 10, 5, 15)
+	mustSave(t, s, "BTCUSDT", "exchange1", minute(1), 12, 11, 13)
+	mustSave(t, s, "BTCUSDT", "exchange2", minute(2), 20, 18, 22)
+	mustSave(t, s, "ETHUSDT", "exchange1", minute(0), 3, 2, 4)
+
+	keys := []domain.SeriesKey{
+		{Symbol: "BTCUSDT"},
+		{Symbol: "BTCUSDT", Exchange: "exchange1"},
+		{Symbol: "ETHUSDT", Exchange: "exchange2"},
+	}
+	latest, err := s.QueryBatch(ctx, domain.StatLatest, domain.TimeRange{}, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 2 {
+		t.Errorf("got %d results, want 2 (the key without data left out)", len(latest))
+	}
+	if r := latest[keys[0]]; r == nil || r.Exchange != "exchange2" || r.Avg != 20 {
+		t.Errorf("latest across exchanges = %+v, want exchange2 at 20", r)
+	}
+	if r := latest[keys[1]]; r == nil || r.Avg != 12 {
+		t.Errorf("latest on exchange1 = %+v, want 12", r)
+	}
+
+	highest, _ := s.QueryBatch(ctx, domain.StatHighest, domain.TimeRange{}, keys[:2])
+	if r := highest[keys[0]]; r == nil || r.Max != 22 {
+		t.Errorf("highest across exchanges = %+v, want max 22", r)
+	}
+	if r := highest[keys[1]]; r == nil || r.Max != 15 {
+		t.Errorf("highest on exchange1 = %+v, want max 15", r)
+	}
+	average, _ := s.QueryBatch(ctx, domain.StatAverage, domain.TimeRange{From: t0, To: minute(2)}, keys[:1])
+	if r := average[keys[0]]; r == nil || r.Avg != 11 {
+		t.Errorf("average over the first two minutes = %+v, want 11", r)
+	}
+
+	if _, err := s.QueryBatch(ctx, "median", domain.TimeRange{}, keys); err == nil {
+		t.Error("unsupported statistic accepted")
+	}
+}
+
+func TestStreamAggregatesAcrossChunks(t *testing.T) {
+	s := NewStore()
+	rows := exportChunk + 100
+	for i := 0; i < rows/2; i++ {
+		mustSave(t, s, "BTCUSDT", "exchange2", minute(i), float64(i), 0, 0)
+		mustSave(t, s, "BTCUSDT", "exchange1", minute(i), float64(i), 0, 0)
+	}
+
+	for _, limit := range []int{rows + 10, exportChunk + 1} {
+		var got []domain.AggregatedResponse
+		err := s.StreamAggregates(context.Background(), domain.ExportQuery{Symbol: "BTCUSDT", Limit: limit}, func(r domain.AggregatedResponse) error {
+			got = append(got, r)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := min(limit, rows); len(got) != want {
+			t.Fatalf("limit %d: streamed %d rows, want %d", limit, len(got), want)
+		}
+		for i, r := range got {
+			wantEx := "exchange1"
+			if i%2 == 1 {
+				wantEx = "exchange2"
+			}
+			if r.Timestamp != minute(i/2).Format(time.RFC3339) || r.Exchange != wantEx {
+				t.Fatalf("limit %d: row %d = %s %s, want %s %s", limit, i, r.Timestamp, r.Exchange, minute(i/2).Format(time.RFC3339), wantEx)
+			}
+		}
+	}
+}
@@ -1,6 +1,14 @@
 package postgres
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
 
 type ApiAdapter struct {
 	db *sql.DB
@@ -18,6 +26,34 @@ func (a *ApiAdapter) Close() error {
 	return a.db.Close()
 }
 
-func (a *ApiAdapter) Ping() error {
-	return a.db.Ping()
+func (a *ApiAdapter) Ping(ctx context.Context) error {
+	defer observeQuery("Ping")()
+	return a.db.PingContext(ctx)
+}
+
+// observeQuery starts timing a repository method; call the returned function
+// when it completes, typically with defer observeQuery("Method")().
+func observeQuery(method string) func() {
+	start := time.Now()
+	return func() { metrics.PostgresQueryDuration.ObserveSince(start, method) }
+}
+
+// rangeCond appends the bounds and resolution of rng to args and returns the
+// matching SQL condition. Only rows of the requested resolution match.
+func rangeCond(rng domain.TimeRange, args []interface{}) (string, []interface{}) {
+	interval := rng.Resolution
+	if interval == 0 {
+		interval = time.Minute
+	}
+	args = append(args, int(interval/time.Second))
+	cond := " AND interval_seconds = $" + strconv.Itoa(len(args))
+	if !rng.From.IsZero() {
+		args = append(args, rng.From)
+		cond += " AND window_start >= $" + strconv.Itoa(len(args))
+	}
+	if !rng.To.IsZero() {
+		args = append(args, rng.To)
+		cond += " AND window_start < $" + strconv.Itoa(len(args))
+	}
+	return cond, args
 }
@@ -1,98 +1,79 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (a *ApiAdapter) GetAvgBySymbol(symbol string) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying average price by symbol", "symbol", symbol)
+func (a *ApiAdapter) QueryAvgInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryAvgInRange")()
+	slog.DebugContext(ctx, "Querying average price by symbol in range", "symbol", symbol, "from", rng.From, "to", rng.To)
 
-	row := a.db.QueryRow(`
-		SELECT AVG(average_price)
+	cond, args := rangeCond(rng, []interface{}{symbol})
+	row := a.db.QueryRowContext(ctx, `
+		SELECT SUM(average_price * minute_count) / SUM(minute_count)
 		FROM aggregated_prices
-		WHERE pair_name = $1
-	`, symbol)
+		WHERE pair_name = $1`+cond, args...)
 
-	var avg float64
-	err := row.Scan(&avg)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Warn("No average price found for symbol", "symbol", symbol)
-			return nil, nil
-		}
-		slog.Error("Failed to scan average price", "symbol", symbol, "err", err)
+	var avg sql.NullFloat64
+	if err := row.Scan(&avg); err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan average price", "symbol", symbol, "from", rng.From, "to", rng.To, "err", err)
 		return nil, err
 	}
+	if !avg.Valid {
+		slog.DebugContext(ctx, "No average price found in range", "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, nil
+	}
 
-	slog.Info("Average price retrieved", "symbol", symbol, "avg", avg)
+	slog.DebugContext(ctx, "Average price retrieved for range", "symbol", symbol, "avg", avg.Float64)
 
 	return &domain.AggregatedResponse{
 		Pair:      symbol,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Avg:       avg,
+		Timestamp: rangeTimestamp(rng),
+		Avg:       avg.Float64,
 	}, nil
 }
 
-func (a *ApiAdapter) GetAvgByExchange(exchange, symbol string) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying average price by exchange and symbol", "exchange", exchange, "symbol", symbol)
+func (a *ApiAdapter) QueryAvgInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryAvgInRangeByExchange")()
+	slog.DebugContext(ctx, "Querying average price by exchange and symbol in range", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
 
-	row := a.db.QueryRow(`
-		SELECT AVG(average_price)
+	cond, args := rangeCond(rng, []interface{}{symbol, exchange})
+	row := a.db.QueryRowContext(ctx, `
+		SELECT SUM(average_price * minute_count) / SUM(minute_count)
 		FROM aggregated_prices
-		WHERE pair_name = $1 AND exchange = $2
-	`, symbol, exchange)
+		WHERE pair_name = $1 AND exchange = $2`+cond, args...)
 
-	var avg float64
-	err := row.Scan(&avg)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Warn("No average price found for exchange and symbol", "exchange", exchange, "symbol", symbol)
-			return nil, nil
-		}
-		slog.Error("Failed to scan average price", "exchange", exchange, "symbol", symbol, "err", err)
+	var avg sql.NullFloat64
+	if err := row.Scan(&avg); err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan average price", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To, "err", err)
 		return nil, err
 	}
+	if !avg.Valid {
+		slog.DebugContext(ctx, "No average price found in range", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, nil
+	}
 
-	slog.Info("Average price retrieved", "exchange", exchange, "symbol", symbol, "avg", avg)
+	slog.DebugContext(ctx, "Average price retrieved for range", "exchange", exchange, "symbol", symbol, "avg", avg.Float64)
 
 	return &domain.AggregatedResponse{
 		Pair:      symbol,
 		Exchange:  exchange,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Avg:       avg,
+		Timestamp: rangeTimestamp(rng),
+		Avg:       avg.Float64,
 	}, nil
 }
 
-func (a *ApiAdapter) QueryAvgSinceByExchange(exchange, symbol string, since time.Time) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying average price by exchange, symbol, and period", "exchange", exchange, "symbol", symbol, "since", since.Format(time.RFC3339))
-
-	row := a.db.QueryRow(`
-		SELECT AVG(average_price)
-		FROM aggregated_prices
-		WHERE pair_name = $1 AND exchange = $2 AND timestamp >= $3
-	`, symbol, exchange, since)
-
-	var avg float64
-	err := row.Scan(&avg)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Warn("No average price found in time range", "exchange", exchange, "symbol", symbol, "since", since)
-			return nil, nil
-		}
-		slog.Error("Failed to scan average price", "exchange", exchange, "symbol", symbol, "since", since, "err", err)
-		return nil, err
+// rangeTimestamp stamps a range aggregate with the end of its range, so the
+// same query always yields the same response.
+func rangeTimestamp(rng domain.TimeRange) string {
+	if rng.To.IsZero() {
+		return time.Now().Format(time.RFC3339)
 	}
-
-	slog.Info("Average price retrieved for period", "exchange", exchange, "symbol", symbol, "avg", avg)
-
-	return &domain.AggregatedResponse{
-		Pair:      symbol,
-		Exchange:  exchange,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Avg:       avg,
-	}, nil
+	return rng.To.Format(time.RFC3339)
 }
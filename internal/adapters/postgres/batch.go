@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+
+	"github.com/lib/pq"
+)
+
+// QueryBatch resolves one statistic over the same range for many series with a
+// single statement. Series are passed as parallel arrays and joined against
+// aggregated_prices; an empty exchange matches every exchange.
+func (a *ApiAdapter) QueryBatch(ctx context.Context, stat string, rng domain.TimeRange, keys []domain.SeriesKey) (map[domain.SeriesKey]*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryBatch")()
+	slog.DebugContext(ctx, "Querying batch statistic", "stat", stat, "series", len(keys), "from", rng.From, "to", rng.To)
+
+	symbols := make([]string, len(keys))
+	exchanges := make([]string, len(keys))
+	for i, k := range keys {
+		symbols[i], exchanges[i] = k.Symbol, k.Exchange
+	}
+	cond, args := rangeCond(rng, []interface{}{pq.Array(symbols), pq.Array(exchanges)})
+
+	const keysCTE = `
+		WITH keys AS (
+			SELECT * FROM unnest($1::text[], $2::text[]) AS k(pair_name, exchange)
+		)`
+	const join = `
+		FROM keys k
+		JOIN aggregated_prices p
+		  ON p.pair_name = k.pair_name AND (k.exchange = '' OR p.exchange = k.exchange)
+		WHERE TRUE`
+
+	var query string
+	switch stat {
+	case domain.StatAverage:
+		query = keysCTE + `
+		SELECT k.pair_name, k.exchange, SUM(p.average_price * p.minute_count) / SUM(p.minute_count)` + join + cond + `
+		GROUP BY k.pair_name, k.exchange`
+	case domain.StatLatest, domain.StatHighest, domain.StatLowest:
+		order := "p.window_start DESC"
+		if stat == domain.StatHighest {
+			order = "p.max_price DESC"
+		} else if stat == domain.StatLowest {
+			order = "p.min_price ASC"
+		}
+		query = keysCTE + `
+		SELECT DISTINCT ON (k.pair_name, k.exchange)
+		       k.pair_name, k.exchange, p.exchange, p.window_start, p.average_price, p.min_price, p.max_price` + join + cond + `
+		ORDER BY k.pair_name, k.exchange, ` + order
+	default:
+		return nil, errors.New("unsupported statistic: " + stat)
+	}
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to query batch statistic", "stat", stat, "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(map[domain.SeriesKey]*domain.AggregatedResponse, len(keys))
+	for rows.Next() {
+		var key domain.SeriesKey
+		var resp domain.AggregatedResponse
+
+		if stat == domain.StatAverage {
+			var avg sql.NullFloat64
+			if err := rows.Scan(&key.Symbol, &key.Exchange, &avg); err != nil {
+				slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan batch average", "err", err)
+				return nil, err
+			}
+			if !avg.Valid {
+				continue
+			}
+			resp = domain.AggregatedResponse{
+				Pair:      key.Symbol,
+				Exchange:  key.Exchange,
+				Timestamp: rangeTimestamp(rng),
+				Avg:       avg.Float64,
+			}
+		} else {
+			var ts time.Time
+			if err := rows.Scan(&key.Symbol, &key.Exchange, &resp.Exchange, &ts, &resp.Avg, &resp.Min, &resp.Max); err != nil {
+				slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan batch statistic", "stat", stat, "err", err)
+				return nil, err
+			}
+			resp.Pair = key.Symbol
+			resp.Timestamp = ts.Format(time.RFC3339)
+		}
+		results[key] = &resp
+	}
+	if err := rows.Err(); err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to iterate batch statistic", "stat", stat, "err", err)
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Batch statistic retrieved", "stat", stat, "series", len(keys), "found", len(results))
+	return results, nil
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+func (a *ApiAdapter) GetCoverage(ctx context.Context) ([]domain.Coverage, error) {
+	defer observeQuery("GetCoverage")()
+	slog.DebugContext(ctx, "Querying aggregate coverage")
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT exchange, pair_name, MIN(window_start), MAX(window_start), COUNT(*)
+		FROM aggregated_prices
+		WHERE interval_seconds = 60
+		GROUP BY exchange, pair_name
+		ORDER BY exchange, pair_name
+	`)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to query aggregate coverage", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coverage []domain.Coverage
+	for rows.Next() {
+		var c domain.Coverage
+		var first, last time.Time
+		if err := rows.Scan(&c.Exchange, &c.Symbol, &first, &last, &c.Rows); err != nil {
+			slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan aggregate coverage", "err", err)
+			return nil, err
+		}
+		c.FirstAggregate = first.Format(time.RFC3339)
+		c.LastAggregate = last.Format(time.RFC3339)
+		coverage = append(coverage, c)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to iterate aggregate coverage", "err", err)
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Aggregate coverage retrieved", "series", len(coverage))
+	return coverage, nil
+}
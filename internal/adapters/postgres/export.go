@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+// exportFetchSize is how many rows each FETCH pulls from the export cursor.
+const exportFetchSize = 500
+
+func exportCond(q domain.ExportQuery) (string, []interface{}) {
+	cond := "pair_name = $1"
+	args := []interface{}{q.Symbol}
+	if q.Exchange != "" {
+		args = append(args, q.Exchange)
+		cond += " AND exchange = $2"
+	}
+	rng, args := rangeCond(q.Range, args)
+	return cond + rng, args
+}
+
+// StreamAggregates calls fn for each selected row in time order. Rows are
+// read through a server-side cursor in a read-only transaction, a batch at a
+// time, so memory use does not grow with the export.
+func (a *ApiAdapter) StreamAggregates(ctx context.Context, q domain.ExportQuery, fn func(domain.AggregatedResponse) error) error {
+	defer observeQuery("StreamAggregates")()
+	slog.DebugContext(ctx, "Streaming aggregates", "symbol", q.Symbol, "exchange", q.Exchange, "from", q.Range.From, "to", q.Range.To, "limit", q.Limit)
+
+	tx, err := a.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cond, args := exportCond(q)
+	args = append(args, q.Limit)
+	_, err = tx.ExecContext(ctx, `
+		DECLARE export_cursor NO SCROLL CURSOR FOR
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
+		FROM aggregated_prices
+		WHERE `+cond+`
+		ORDER BY window_start ASC, exchange ASC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to open export cursor", "symbol", q.Symbol, "err", err)
+		return err
+	}
+
+	for {
+		n, err := fetchExportBatch(ctx, tx, fn)
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			return tx.Commit()
+		}
+	}
+}
+
+func fetchExportBatch(ctx context.Context, tx *sql.Tx, fn func(domain.AggregatedResponse) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, `FETCH `+strconv.Itoa(exportFetchSize)+` FROM export_cursor`)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to fetch export rows", "err", err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var p domain.AggregatedResponse
+		var ts time.Time
+		if err := rows.Scan(&p.Pair, &p.Exchange, &ts, &p.Avg, &p.Min, &p.Max); err != nil {
+			return n, err
+		}
+		p.Timestamp = ts.Format(time.RFC3339)
+		if err := fn(p); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+func (a *ApiAdapter) GetHistory(ctx context.Context, q domain.HistoryQuery) ([]domain.HistoryRecord, error) {
+	defer observeQuery("GetHistory")()
+	slog.DebugContext(ctx, "Querying price history", "exchange", q.Exchange, "symbol", q.Symbol, "from", q.Range.From, "to", q.Range.To, "limit", q.Limit)
+
+	conds := []string{"pair_name = $1"}
+	args := []interface{}{q.Symbol}
+	addCond := func(expr string, vals ...interface{}) {
+		for _, v := range vals {
+			args = append(args, v)
+			expr = strings.Replace(expr, "?", "$"+strconv.Itoa(len(args)), 1)
+		}
+		conds = append(conds, expr)
+	}
+
+	if q.Exchange != "" {
+		addCond("exchange = ?", q.Exchange)
+	}
+	if !q.AfterTS.IsZero() {
+		addCond("(window_start, id) > (?, ?)", q.AfterTS, q.AfterID)
+	}
+	rangeSQL, args := rangeCond(q.Range, args)
+	args = append(args, q.Limit)
+
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id, pair_name, exchange, window_start, average_price, min_price, max_price
+		FROM aggregated_prices
+		WHERE `+strings.Join(conds, " AND ")+rangeSQL+`
+		ORDER BY window_start ASC, id ASC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to query price history", "symbol", q.Symbol, "exchange", q.Exchange, "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []domain.HistoryRecord
+	for rows.Next() {
+		var rec domain.HistoryRecord
+		var avg, min, max float64
+		if err := rows.Scan(&rec.ID, &rec.Price.Pair, &rec.Price.Exchange, &rec.Timestamp, &avg, &min, &max); err != nil {
+			slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan price history row", "symbol", q.Symbol, "err", err)
+			return nil, err
+		}
+		rec.Price.Timestamp = rec.Timestamp.Format(time.RFC3339)
+		rec.Price.Avg = avg
+		rec.Price.Min = min
+		rec.Price.Max = max
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to iterate price history", "symbol", q.Symbol, "err", err)
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Price history retrieved", "symbol", q.Symbol, "exchange", q.Exchange, "rows", len(records))
+	return records, nil
+}
@@ -1,21 +1,24 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (a *ApiAdapter) GetPriceForSymbol(symbol string) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying latest price for symbol", "symbol", symbol)
+func (a *ApiAdapter) GetPriceForSymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error) {
+	defer observeQuery("GetPriceForSymbol")()
+	slog.DebugContext(ctx, "Querying latest price for symbol", "symbol", symbol)
 
-	row := a.db.QueryRow(`
-		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+	row := a.db.QueryRowContext(ctx, `
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
 		FROM aggregated_prices
-		WHERE pair_name = $1
-		ORDER BY timestamp DESC
+		WHERE pair_name = $1 AND interval_seconds = 60
+		ORDER BY window_start DESC
 		LIMIT 1
 	`, symbol)
 
@@ -26,14 +29,14 @@ func (a *ApiAdapter) GetPriceForSymbol(symbol string) (*domain.AggregatedRespons
 	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.Warn("No latest price found for symbol", "symbol", symbol)
+			slog.DebugContext(ctx, "No latest price found for symbol", "symbol", symbol)
 			return nil, nil
 		}
-		slog.Error("Failed to scan latest price for symbol", "symbol", symbol, "err", err)
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan latest price for symbol", "symbol", symbol, "err", err)
 		return nil, err
 	}
 
-	slog.Info("Latest price retrieved", "symbol", symbol, "avg", avg, "timestamp", ts)
+	slog.DebugContext(ctx, "Latest price retrieved", "symbol", symbol, "avg", avg, "timestamp", ts)
 
 	return &domain.AggregatedResponse{
 		Pair:      pair,
@@ -45,14 +48,15 @@ func (a *ApiAdapter) GetPriceForSymbol(symbol string) (*domain.AggregatedRespons
 	}, nil
 }
 
-func (a *ApiAdapter) GetPriceForExchange(exchange, symbol string) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying latest price for symbol by exchange", "exchange", exchange, "symbol", symbol)
+func (a *ApiAdapter) GetPriceForExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error) {
+	defer observeQuery("GetPriceForExchange")()
+	slog.DebugContext(ctx, "Querying latest price for symbol by exchange", "exchange", exchange, "symbol", symbol)
 
-	row := a.db.QueryRow(`
-		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+	row := a.db.QueryRowContext(ctx, `
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
 		FROM aggregated_prices
-		WHERE pair_name = $1 AND exchange = $2
-		ORDER BY timestamp DESC
+		WHERE pair_name = $1 AND exchange = $2 AND interval_seconds = 60
+		ORDER BY window_start DESC
 		LIMIT 1
 	`, symbol, exchange)
 
@@ -63,14 +67,14 @@ func (a *ApiAdapter) GetPriceForExchange(exchange, symbol string) (*domain.Aggre
 	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.Warn("No latest price found for symbol and exchange", "symbol", symbol, "exchange", exchange)
+			slog.DebugContext(ctx, "No latest price found for symbol and exchange", "symbol", symbol, "exchange", exchange)
 			return nil, nil
 		}
-		slog.Error("Failed to scan latest price", "symbol", symbol, "exchange", exchange, "err", err)
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan latest price", "symbol", symbol, "exchange", exchange, "err", err)
 		return nil, err
 	}
 
-	slog.Info("Latest price retrieved", "symbol", symbol, "exchange", exchange, "avg", avg, "timestamp", ts)
+	slog.DebugContext(ctx, "Latest price retrieved", "symbol", symbol, "exchange", exchange, "avg", avg, "timestamp", ts)
 
 	return &domain.AggregatedResponse{
 		Pair:      pair,
@@ -81,3 +85,79 @@ func (a *ApiAdapter) GetPriceForExchange(exchange, symbol string) (*domain.Aggre
 		Max:       max,
 	}, nil
 }
+
+func (a *ApiAdapter) QueryLatestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryLatestInRange")()
+	slog.DebugContext(ctx, "Querying latest price by symbol in range", "symbol", symbol, "from", rng.From, "to", rng.To)
+
+	cond, args := rangeCond(rng, []interface{}{symbol})
+	row := a.db.QueryRowContext(ctx, `
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
+		FROM aggregated_prices
+		WHERE pair_name = $1`+cond+`
+		ORDER BY window_start DESC
+		LIMIT 1
+	`, args...)
+
+	var pair, exchange string
+	var ts time.Time
+	var avg, min, max float64
+
+	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			slog.DebugContext(ctx, "No latest price found for symbol in range", "symbol", symbol, "from", rng.From, "to", rng.To)
+			return nil, nil
+		}
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan latest price", "symbol", symbol, "from", rng.From, "to", rng.To, "err", err)
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Latest price retrieved", "symbol", symbol, "from", rng.From, "to", rng.To, "avg", avg)
+	return &domain.AggregatedResponse{
+		Pair:      pair,
+		Exchange:  exchange,
+		Timestamp: ts.Format(time.RFC3339),
+		Avg:       avg,
+		Min:       min,
+		Max:       max,
+	}, nil
+}
+
+func (a *ApiAdapter) QueryLatestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryLatestInRangeByExchange")()
+	slog.DebugContext(ctx, "Querying latest price by exchange and symbol in range", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+
+	cond, args := rangeCond(rng, []interface{}{symbol, exchange})
+	row := a.db.QueryRowContext(ctx, `
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
+		FROM aggregated_prices
+		WHERE pair_name = $1 AND exchange = $2`+cond+`
+		ORDER BY window_start DESC
+		LIMIT 1
+	`, args...)
+
+	var pair string
+	var ts time.Time
+	var avg, min, max float64
+
+	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			slog.DebugContext(ctx, "No latest price found for exchange and symbol in range", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+			return nil, nil
+		}
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan latest price", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To, "err", err)
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Latest price retrieved", "exchange", exchange, "symbol", symbol, "avg", avg)
+	return &domain.AggregatedResponse{
+		Pair:      pair,
+		Exchange:  exchange,
+		Timestamp: ts.Format(time.RFC3339),
+		Avg:       avg,
+		Min:       min,
+		Max:       max,
+	}, nil
+}
@@ -1,23 +1,27 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (a *ApiAdapter) GetLowestBySymbol(symbol string) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying lowest price by symbol", "symbol", symbol)
+func (a *ApiAdapter) QueryLowestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryLowestInRange")()
+	slog.DebugContext(ctx, "Querying lowest price by symbol in range", "symbol", symbol, "from", rng.From, "to", rng.To)
 
-	row := a.db.QueryRow(`
-		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+	cond, args := rangeCond(rng, []interface{}{symbol})
+	row := a.db.QueryRowContext(ctx, `
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
 		FROM aggregated_prices
-		WHERE pair_name = $1
-		ORDER BY average_price ASC
+		WHERE pair_name = $1`+cond+`
+		ORDER BY min_price ASC
 		LIMIT 1
-	`, symbol)
+	`, args...)
 
 	var pair, exchange string
 	var ts time.Time
@@ -26,14 +30,14 @@ func (a *ApiAdapter) GetLowestBySymbol(symbol string) (*domain.AggregatedRespons
 	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.Warn("No lowest price found for symbol", "symbol", symbol)
+			slog.DebugContext(ctx, "No lowest price found for symbol in range", "symbol", symbol, "from", rng.From, "to", rng.To)
 			return nil, nil
 		}
-		slog.Error("Failed to scan lowest price by symbol", "symbol", symbol, "err", err)
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan lowest price", "symbol", symbol, "from", rng.From, "to", rng.To, "err", err)
 		return nil, err
 	}
 
-	slog.Info("Lowest price retrieved", "symbol", symbol, "avg", avg)
+	slog.DebugContext(ctx, "Lowest price retrieved", "symbol", symbol, "from", rng.From, "to", rng.To, "avg", avg)
 	return &domain.AggregatedResponse{
 		Pair:      pair,
 		Exchange:  exchange,
@@ -44,16 +48,18 @@ func (a *ApiAdapter) GetLowestBySymbol(symbol string) (*domain.AggregatedRespons
 	}, nil
 }
 
-func (a *ApiAdapter) GetLowestByExchange(exchange, symbol string) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying lowest price by exchange and symbol", "exchange", exchange, "symbol", symbol)
+func (a *ApiAdapter) QueryLowestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	defer observeQuery("QueryLowestInRangeByExchange")()
+	slog.DebugContext(ctx, "Querying lowest price by exchange and symbol in range", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
 
-	row := a.db.QueryRow(`
-		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
+	cond, args := rangeCond(rng, []interface{}{symbol, exchange})
+	row := a.db.QueryRowContext(ctx, `
+		SELECT pair_name, exchange, window_start, average_price, min_price, max_price
 		FROM aggregated_prices
-		WHERE pair_name = $1 AND exchange = $2
-		ORDER BY average_price ASC
+		WHERE pair_name = $1 AND exchange = $2`+cond+`
+		ORDER BY min_price ASC
 		LIMIT 1
-	`, symbol, exchange)
+	`, args...)
 
 	var pair string
 	var ts time.Time
@@ -62,86 +68,14 @@ func (a *ApiAdapter) GetLowestByExchange(exchange, symbol string) (*domain.Aggre
 	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			slog.Warn("No lowest price found for exchange and symbol", "exchange", exchange, "symbol", symbol)
+			slog.DebugContext(ctx, "No lowest price found for exchange and symbol in range", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
 			return nil, nil
 		}
-		slog.Error("Failed to scan lowest price by exchange", "exchange", exchange, "symbol", symbol, "err", err)
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to scan lowest price", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To, "err", err)
 		return nil, err
 	}
 
-	slog.Info("Lowest price retrieved", "exchange", exchange, "symbol", symbol, "avg", avg)
-	return &domain.AggregatedResponse{
-		Pair:      pair,
-		Exchange:  exchange,
-		Timestamp: ts.Format(time.RFC3339),
-		Avg:       avg,
-		Min:       min,
-		Max:       max,
-	}, nil
-}
-
-func (a *ApiAdapter) QueryLowestPriceSince(symbol string, since time.Time) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying lowest price by symbol since time", "symbol", symbol, "since", since)
-
-	row := a.db.QueryRow(`
-		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
-		FROM aggregated_prices
-		WHERE pair_name = $1 AND timestamp >= $2
-		ORDER BY average_price ASC
-		LIMIT 1
-	`, symbol, since)
-
-	var pair, exchange string
-	var ts time.Time
-	var avg, min, max float64
-
-	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Warn("No lowest price found for symbol in period", "symbol", symbol, "since", since)
-			return nil, nil
-		}
-		slog.Error("Failed to scan lowest price by symbol since", "symbol", symbol, "since", since, "err", err)
-		return nil, err
-	}
-
-	slog.Info("Lowest price retrieved", "symbol", symbol, "since", since, "avg", avg)
-	return &domain.AggregatedResponse{
-		Pair:      pair,
-		Exchange:  exchange,
-		Timestamp: ts.Format(time.RFC3339),
-		Avg:       avg,
-		Min:       min,
-		Max:       max,
-	}, nil
-}
-
-func (a *ApiAdapter) QueryLowestSinceByExchange(exchange, symbol string, since time.Time) (*domain.AggregatedResponse, error) {
-	slog.Info("Querying lowest price by exchange and symbol since", "exchange", exchange, "symbol", symbol, "since", since)
-
-	row := a.db.QueryRow(`
-		SELECT pair_name, exchange, timestamp, average_price, min_price, max_price
-		FROM aggregated_prices
-		WHERE pair_name = $1 AND exchange = $2 AND timestamp >= $3
-		ORDER BY average_price ASC
-		LIMIT 1
-	`, symbol, exchange, since)
-
-	var pair string
-	var ts time.Time
-	var avg, min, max float64
-
-	err := row.Scan(&pair, &exchange, &ts, &avg, &min, &max)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			slog.Warn("No lowest price found for exchange, symbol, and period", "exchange", exchange, "symbol", symbol, "since", since)
-			return nil, nil
-		}
-		slog.Error("Failed to scan lowest price by exchange and symbol since", "exchange", exchange, "symbol", symbol, "since", since, "err", err)
-		return nil, err
-	}
-
-	slog.Info("Lowest price retrieved", "exchange", exchange, "symbol", symbol, "since", since, "avg", avg)
+	slog.DebugContext(ctx, "Lowest price retrieved", "exchange", exchange, "symbol", symbol, "avg", avg)
 	return &domain.AggregatedResponse{
 		Pair:      pair,
 		Exchange:  exchange,
@@ -0,0 +1,2 @@
+-- The original spelling of exchange IDs is not recorded, so there is nothing
+-- to undo.
@@ -0,0 +1,2 @@
+-- Exchange IDs are canonically lower-case ("exchange1"); earlier builds stored "Exchange1".
+UPDATE aggregated_prices SET exchange = LOWER(exchange) WHERE exchange <> LOWER(exchange);
@@ -0,0 +1,5 @@
+-- Rows of other intervals cannot be told apart without interval_seconds.
+DELETE FROM aggregated_prices WHERE interval_seconds <> 60;
+ALTER TABLE aggregated_prices DROP CONSTRAINT IF EXISTS aggregated_prices_window_key;
+ALTER TABLE aggregated_prices DROP COLUMN IF EXISTS interval_seconds;
+ALTER TABLE aggregated_prices RENAME COLUMN window_start TO timestamp;
@@ -0,0 +1,28 @@
+-- Each row is the aggregate of one window: keyed by pair, exchange, window
+-- start and window length, so re-aggregating a window updates it in place.
+-- Guarded so databases initialised before the runner existed can catch up.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'aggregated_prices' AND column_name = 'timestamp') THEN
+        ALTER TABLE aggregated_prices RENAME COLUMN timestamp TO window_start;
+    END IF;
+END $$;
+ALTER TABLE aggregated_prices ADD COLUMN IF NOT EXISTS interval_seconds INTEGER NOT NULL DEFAULT 60;
+
+-- Keep only the most recently written row of any window stored twice.
+DELETE FROM aggregated_prices a
+USING aggregated_prices b
+WHERE a.pair_name = b.pair_name
+  AND a.exchange = b.exchange
+  AND a.window_start = b.window_start
+  AND a.interval_seconds = b.interval_seconds
+  AND a.id < b.id;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'aggregated_prices_window_key') THEN
+        ALTER TABLE aggregated_prices
+            ADD CONSTRAINT aggregated_prices_window_key UNIQUE (pair_name, exchange, window_start, interval_seconds);
+    END IF;
+END $$;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_aggregated_prices_interval_time;
@@ -0,0 +1,4 @@
+-- Rollups (5m, 1h, 1d) share aggregated_prices with the minute rows and are
+-- told apart by interval_seconds. The rollup job reads and prunes by
+-- resolution and time.
+CREATE INDEX IF NOT EXISTS idx_aggregated_prices_interval_time ON aggregated_prices (interval_seconds, window_start);
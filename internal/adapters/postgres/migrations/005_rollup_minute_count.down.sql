@@ -0,0 +1 @@
+ALTER TABLE aggregated_prices DROP COLUMN IF EXISTS minute_count;
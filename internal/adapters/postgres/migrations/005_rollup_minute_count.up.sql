@@ -0,0 +1,5 @@
+-- Rollup rows record how many minute rows they were built from, so averages
+-- over them weigh every minute equally, as averages over minute rows do.
+-- Rollups written before this are assumed to be complete buckets.
+ALTER TABLE aggregated_prices ADD COLUMN IF NOT EXISTS minute_count INTEGER NOT NULL DEFAULT 1;
+UPDATE aggregated_prices SET minute_count = interval_seconds / 60 WHERE interval_seconds > 60;
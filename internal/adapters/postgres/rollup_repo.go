@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/logging"
+)
+
+// Rollup writes target-interval aggregates for every bucket starting in
+// [from, to), computed from the source-interval rows it contains: the average
+// of their averages weighted by the minutes behind each, the lowest minimum
+// and the highest maximum. Buckets are aligned to UTC midnight, and rewriting
+// a bucket replaces its values.
+func (a *Adapter) Rollup(ctx context.Context, source, target time.Duration, from, to time.Time) (int64, error) {
+	defer observeQuery("Rollup")()
+
+	res, err := a.db.ExecContext(ctx, `
+		INSERT INTO aggregated_prices (pair_name, exchange, window_start, interval_seconds, average_price, min_price, max_price, minute_count)
+		SELECT pair_name, exchange,
+		       date_bin(make_interval(secs => $2::int), window_start, TIMESTAMP '2000-01-01'),
+		       $2::int, SUM(average_price * minute_count) / SUM(minute_count), MIN(min_price), MAX(max_price), SUM(minute_count)
+		FROM aggregated_prices
+		WHERE interval_seconds = $1 AND window_start >= $3 AND window_start < $4
+		GROUP BY 1, 2, 3
+		ON CONFLICT (pair_name, exchange, window_start, interval_seconds) DO UPDATE
+		SET average_price = EXCLUDED.average_price,
+		    min_price = EXCLUDED.min_price,
+		    max_price = EXCLUDED.max_price,
+		    minute_count = EXCLUDED.minute_count
+	`, int(source/time.Second), int(target/time.Second), from, to)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to roll up aggregates", "source", source, "target", target, "from", from, "to", to, "err", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneAggregates deletes rows of the given interval that start before before.
+func (a *Adapter) PruneAggregates(ctx context.Context, interval time.Duration, before time.Time) (int64, error) {
+	defer observeQuery("PruneAggregates")()
+
+	res, err := a.db.ExecContext(ctx, `
+		DELETE FROM aggregated_prices
+		WHERE interval_seconds = $1 AND window_start < $2
+	`, int(interval/time.Second), before)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to prune aggregates", "interval", interval, "before", before, "err", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
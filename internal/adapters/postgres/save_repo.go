@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"log/slog"
 	"time"
+
+	"marketflow/internal/logging"
 )
 
 type Adapter struct {
@@ -27,30 +29,38 @@ func (a *Adapter) Close() error {
 	return a.db.Close()
 }
 
+// SaveAggregatedPrice writes the one-minute aggregate for the window starting
+// at ts. Writing a window again replaces its values, so re-aggregation and
+// backfills never produce duplicate rows.
 func (a *Adapter) SaveAggregatedPrice(ctx context.Context, pair, exchange string, ts time.Time, avg, min, max float64) error {
-	slog.Info("Saving aggregated price",
+	defer observeQuery("SaveAggregatedPrice")()
+	slog.DebugContext(ctx, "Saving aggregated price",
 		"pair", pair,
 		"exchange", exchange,
-		"timestamp", ts,
+		"window_start", ts,
 		"avg", avg,
 		"min", min,
 		"max", max,
 	)
 
 	_, err := a.db.ExecContext(ctx, `
-		INSERT INTO aggregated_prices (pair_name, exchange, timestamp, average_price, min_price, max_price)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO aggregated_prices (pair_name, exchange, window_start, interval_seconds, average_price, min_price, max_price)
+		VALUES ($1, $2, $3, 60, $4, $5, $6)
+		ON CONFLICT (pair_name, exchange, window_start, interval_seconds) DO UPDATE
+		SET average_price = EXCLUDED.average_price,
+		    min_price = EXCLUDED.min_price,
+		    max_price = EXCLUDED.max_price
 	`, pair, exchange, ts, avg, min, max)
 	if err != nil {
-		slog.Error("Failed to save aggregated price",
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "Failed to save aggregated price",
 			"pair", pair,
 			"exchange", exchange,
-			"timestamp", ts,
+			"window_start", ts,
 			"err", err,
 		)
 		return err
 	}
 
-	slog.Info("Successfully saved aggregated price", "pair", pair, "exchange", exchange)
+	slog.DebugContext(ctx, "Successfully saved aggregated price", "pair", pair, "exchange", exchange)
 	return nil
 }
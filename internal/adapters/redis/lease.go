@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireLease takes the lease if it is free and extends it if owner already
+// holds it, in one round trip.
+var acquireLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseLease deletes the lease only if owner still holds it.
+var releaseLease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLease takes or renews the lease at key for owner, reporting whether
+// owner holds it for the next ttl.
+func (r *Adapter) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	held, err := acquireLease.Run(ctx, r.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		slog.Error("AcquireLease failed", "key", key, "owner", owner, "err", err)
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// ReleaseLease gives up the lease at key if owner holds it.
+func (r *Adapter) ReleaseLease(ctx context.Context, key, owner string) error {
+	err := releaseLease.Run(ctx, r.client, []string{key}, owner).Err()
+	if err != nil {
+		slog.Error("ReleaseLease failed", "key", key, "owner", owner, "err", err)
+	}
+	return err
+}
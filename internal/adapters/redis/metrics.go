@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"marketflow/internal/metrics"
+)
+
+// metricsHook records the latency and failures of every Redis command.
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		metrics.RedisOpDuration.ObserveSince(start, cmd.Name())
+		if err != nil && !errors.Is(err, redis.Nil) {
+			metrics.RedisErrors.Inc(cmd.Name())
+		}
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		metrics.RedisOpDuration.ObserveSince(start, "pipeline")
+		if err != nil && !errors.Is(err, redis.Nil) {
+			metrics.RedisErrors.Inc("pipeline")
+		}
+		return err
+	}
+}
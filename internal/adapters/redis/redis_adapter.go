@@ -22,6 +22,7 @@ func NewRedisAdapter(addr, password string, db int) *Adapter {
 		Password: password,
 		DB:       db,
 	})
+	client.AddHook(metricsHook{})
 
 	return &Adapter{client: client}
 }
@@ -31,7 +32,7 @@ func (r *Adapter) Ping(ctx context.Context) error {
 	if err != nil {
 		slog.Error("Redis ping failed", "err", err)
 	} else {
-		slog.Info("Redis ping successful")
+		slog.Debug("Redis ping successful")
 	}
 	return err
 }
@@ -56,7 +57,7 @@ func (r *Adapter) ZRangeByScore(ctx context.Context, key string, min, max int64)
 	if err != nil {
 		slog.Error("ZRangeByScore failed", "key", key, "min", min, "max", max, "err", err)
 	} else {
-		slog.Info("ZRangeByScore success", "key", key, "count", len(result))
+		slog.Debug("ZRangeByScore success", "key", key, "count", len(result))
 	}
 	return result, err
 }
@@ -71,3 +72,17 @@ func (r *Adapter) ZRemRangeByScore(ctx context.Context, key string, min, max int
 	}
 	return err
 }
+
+// ZMaxScore returns the highest score in the sorted set, reporting false when
+// the set is empty or missing.
+func (r *Adapter) ZMaxScore(ctx context.Context, key string) (int64, bool, error) {
+	result, err := r.client.ZRevRangeWithScores(ctx, key, 0, 0).Result()
+	if err != nil {
+		slog.Error("ZMaxScore failed", "key", key, "err", err)
+		return 0, false, err
+	}
+	if len(result) == 0 {
+		return 0, false, nil
+	}
+	return int64(result[0].Score), true, nil
+}
@@ -2,14 +2,28 @@ package websocket
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net"
+	"sync"
 	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
 )
 
+// Sink accepts parsed ticks and applies the source's backpressure policy.
+type Sink interface {
+	Send(ctx context.Context, update domain.PriceUpdate) bool
+}
+
+type Source struct {
+	Name    string
+	Address string
+	Sink    Sink
+}
+
 type Ticker struct {
 	Symbol    string  `json:"symbol"`
 	Price     float64 `json:"price"`
@@ -17,23 +31,32 @@ type Ticker struct {
 }
 
 // Fan-In pattern
-func connectAndRead(name, address string, out chan<- domain.PriceUpdate) {
-	for {
-		conn, err := net.Dial("tcp", address)
+func connectAndRead(ctx context.Context, name, address string, sink Sink) {
+	var dialer net.Dialer
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			metrics.SourceReconnects.Inc(name)
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
 		if err != nil {
 			slog.Error("Connection failed",
 				"exchange", name,
 				"address", address,
 				"err", err,
 			)
-			time.Sleep(2 * time.Second)
+			if !sleepCtx(ctx, 2*time.Second) {
+				return
+			}
 			continue
 		}
+		// Unblock the scanner when the mode is switched away.
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
 		scanner := bufio.NewScanner(conn)
 		for scanner.Scan() {
 			var t Ticker
 			line := scanner.Text()
 			if err := json.Unmarshal([]byte(line), &t); err != nil {
+				metrics.ParseErrors.Inc(name)
 				slog.Error("Failed to parse ticker JSON",
 					"exchange", name,
 					"raw", line,
@@ -42,14 +65,26 @@ func connectAndRead(name, address string, out chan<- domain.PriceUpdate) {
 				continue
 			}
 
-			out <- domain.PriceUpdate{
-				Symbol:    t.Symbol,
+			update := domain.PriceUpdate{
+				Symbol:    domain.CanonicalSymbol(t.Symbol),
 				Price:     t.Price,
 				Timestamp: t.Timestamp,
 				Exchange:  name,
 			}
+			if !sink.Send(ctx, update) {
+				stop()
+				conn.Close()
+				slog.Info("Exchange reader stopped", "exchange", name)
+				return
+			}
 		}
 
+		stop()
+		if ctx.Err() != nil {
+			conn.Close()
+			slog.Info("Exchange reader stopped", "exchange", name)
+			return
+		}
 		if err := scanner.Err(); err != nil {
 			slog.Warn("Scanner error occurred",
 				"exchange", name,
@@ -57,13 +92,32 @@ func connectAndRead(name, address string, out chan<- domain.PriceUpdate) {
 			)
 		}
 		conn.Close()
-		time.Sleep(2 * time.Second)
+		if !sleepCtx(ctx, 2*time.Second) {
+			return
+		}
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
 	}
 }
 
-func StartReaders(out chan<- domain.PriceUpdate) {
+// StartReaders connects to every source and returns once ctx is done and
+// every reader has stopped.
+func StartReaders(ctx context.Context, sources []Source) {
 	slog.Info("[LIVE MODE] Starting WebSocket Readers...")
-	go connectAndRead("Exchange1", "exchange1:40101", out)
-	go connectAndRead("Exchange2", "exchange2:40102", out)
-	go connectAndRead("Exchange3", "exchange3:40103", out)
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			connectAndRead(ctx, src.Name, src.Address, src.Sink)
+		}(src)
+	}
+	wg.Wait()
 }
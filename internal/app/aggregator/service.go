@@ -4,6 +4,9 @@ import (
 	"context"
 	"log/slog"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"marketflow/internal/app"
@@ -13,20 +16,49 @@ import (
 type ServiceCom struct {
 	pgSave    app.SavePGRepo
 	redisRepo app.RedisRepo
+	registry  *domain.Registry
+	publisher app.AggregatePublisher
+
+	// retention is how long ticks stay in Redis, and so how far back missed
+	// windows can still be aggregated.
+	retention time.Duration
+	workers   sync.WaitGroup
+
+	// tickSeq numbers stored ticks so that each is a distinct member.
+	tickSeq atomic.Uint64
+
+	// nextWindow is the start of the oldest window not yet aggregated.
+	mu         sync.Mutex
+	nextWindow time.Time
 }
 
-func NewServiceCom(redisAdapter app.RedisRepo, pgAdapter app.SavePGRepo) *ServiceCom {
-	return &ServiceCom{redisRepo: redisAdapter, pgSave: pgAdapter}
+// DefaultTickRetention keeps a quarter of an hour of ticks in Redis.
+const DefaultTickRetention = 15 * time.Minute
+
+// NewServiceCom builds the ingest/aggregation service. publisher may be nil;
+// otherwise every saved aggregate is also published to it. retention bounds
+// both the ticks kept in Redis and how far back missed windows are filled;
+// zero means DefaultTickRetention.
+func NewServiceCom(redisAdapter app.RedisRepo, pgAdapter app.SavePGRepo, registry *domain.Registry, publisher app.AggregatePublisher, retention time.Duration) *ServiceCom {
+	if retention <= 0 {
+		retention = DefaultTickRetention
+	}
+	return &ServiceCom{redisRepo: redisAdapter, pgSave: pgAdapter, registry: registry, publisher: publisher, retention: retention}
 }
 
+// StartRedisWorkerPool stores updates from input in Redis until input is
+// closed; WaitWorkers reports when every worker has drained it.
 func (ls *ServiceCom) StartRedisWorkerPool(ctx context.Context, input <-chan domain.PriceUpdate, workers int) {
 	for i := 0; i < workers; i++ {
+		ls.workers.Add(1)
 		go func(id int) {
+			defer ls.workers.Done()
 			slog.Info("Redis worker started", "worker_id", id)
 			for update := range input {
-				key := "price:" + update.Symbol + ":" + update.Exchange
-				timestamp := time.Now().Unix()
-				value := strconv.FormatFloat(update.Price, 'f', -1, 64)
+				key := domain.PriceKey(update.Symbol, update.Exchange)
+				now := time.Now()
+				timestamp := now.Unix()
+				value := ls.tickMember(update.Price, now)
 
 				if err := ls.redisRepo.ZAdd(ctx, key, timestamp, value); err != nil {
 					slog.Error("Failed to ZAdd to Redis", "worker_id", id, "key", key, "err", err)
@@ -34,80 +66,47 @@ func (ls *ServiceCom) StartRedisWorkerPool(ctx context.Context, input <-chan dom
 				}
 				slog.Debug("ZAdd success", "worker_id", id, "key", key, "price", update.Price)
 
-				if err := ls.redisRepo.ZRemRangeByScore(ctx, key, 0, timestamp-61); err != nil {
+				if err := ls.redisRepo.ZRemRangeByScore(ctx, key, 0, timestamp-int64(ls.retention/time.Second)); err != nil {
 					slog.Warn("Failed to clean up old entries in Redis", "worker_id", id, "key", key, "err", err)
 				}
 			}
-			slog.Warn("Redis worker exiting", "worker_id", id)
+			slog.Info("Redis worker exiting", "worker_id", id)
 		}(i)
 	}
 }
 
-func (ls *ServiceCom) StartAggregator(ctx context.Context) {
-	slog.Info("Aggregator started")
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	pairs := domain.TradingPairs
-	exchanges := domain.ExchangeNames
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Warn("Aggregator context cancelled, stopping...")
-			return
-		case <-ticker.C:
-			now := time.Now()
-			currentTimestamp := now.Unix()
-
-			for _, pair := range pairs {
-				for _, ex := range exchanges {
-					key := "price:" + pair + ":" + ex
-					values, err := ls.redisRepo.ZRangeByScore(ctx, key, currentTimestamp-60, currentTimestamp)
-					if err != nil {
-						slog.Error("Failed to get prices from Redis", "key", key, "err", err)
-						continue
-					}
-					if len(values) == 0 {
-						slog.Debug("No prices found in Redis", "key", key)
-						continue
-					}
-
-					var prices []float64
-					for _, v := range values {
-						price, err := strconv.ParseFloat(v, 64)
-						if err != nil {
-							slog.Warn("Failed to parse price from Redis", "value", v, "err", err)
-							continue
-						}
-						prices = append(prices, price)
-					}
+// tickMember is the sorted-set member storing a tick: its price, then the
+// time it was stored and a sequence number. Members are unique per key, so a
+// bare price would count a repeated price only once per window.
+func (ls *ServiceCom) tickMember(price float64, now time.Time) string {
+	return strconv.FormatFloat(price, 'f', -1, 64) + ":" +
+		strconv.FormatInt(now.UnixNano(), 10) + ":" + strconv.FormatUint(ls.tickSeq.Add(1), 10)
+}
 
-					if len(prices) == 0 {
-						slog.Debug("No valid prices parsed for aggregation", "key", key)
-						continue
-					}
+// tickPrice parses the price out of a member written by tickMember, or of a
+// bare price stored by earlier versions.
+func tickPrice(member string) (float64, error) {
+	price, _, _ := strings.Cut(member, ":")
+	return strconv.ParseFloat(price, 64)
+}
 
-					min, max, avg := calcStats(prices)
-					err = ls.pgSave.SaveAggregatedPrice(ctx, pair, ex, now, avg, min, max)
-					if err != nil {
-						slog.Error("Failed to save aggregated price to DB", "pair", pair, "exchange", ex, "err", err)
-					} else {
-						slog.Info("Aggregated price saved",
-							"pair", pair,
-							"exchange", ex,
-							"avg", avg,
-							"min", min,
-							"max", max,
-						)
-					}
-				}
-			}
-		}
+// WaitWorkers blocks until every Redis worker has exited or ctx is done.
+func (ls *ServiceCom) WaitWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		ls.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-func calcStats(prices []float64) (min, max, avg float64) {
+// CalcStats returns the lowest, highest and mean of prices, all zero for none.
+func CalcStats(prices []float64) (min, max, avg float64) {
 	if len(prices) == 0 {
 		return 0, 0, 0
 	}
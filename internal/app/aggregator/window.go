@@ -0,0 +1,214 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
+
+// Window is the aggregation interval. Windows are aligned to UTC minute
+// boundaries and half-open: [start, start+Window).
+const Window = time.Minute
+
+// settleDelay gives ticks received just before a boundary time to reach Redis
+// before their window is read.
+const settleDelay = 2 * time.Second
+
+func windowStart(t time.Time) time.Time {
+	return t.UTC().Truncate(Window)
+}
+
+// StartAggregator aggregates every completed window shortly after each minute
+// boundary. Windows missed while the aggregator was delayed or failing are
+// filled in on the next run, as far back as the tick retention allows. On its
+// first run it also redoes the previous minute, which a replica that stopped
+// being leader may not have finished.
+func (ls *ServiceCom) StartAggregator(ctx context.Context) {
+	slog.Info("Aggregator started", "window", Window, "retention", ls.retention)
+
+	ls.mu.Lock()
+	if ls.nextWindow.IsZero() {
+		ls.nextWindow = windowStart(time.Now()).Add(-Window)
+	}
+	ls.mu.Unlock()
+
+	for {
+		wait := time.Until(windowStart(time.Now()).Add(Window + settleDelay))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Warn("Aggregator context cancelled, stopping...")
+			return
+		case <-timer.C:
+			ls.catchUp(ctx, time.Now())
+		}
+	}
+}
+
+// Flush aggregates every window still pending, including the current partial
+// one. It is run once at shutdown, after the aggregator has stopped and the
+// Redis workers have drained; the saves are synchronous, so Postgres holds
+// every row when it returns.
+func (ls *ServiceCom) Flush(ctx context.Context) error {
+	now := time.Now()
+	if err := ls.catchUp(ctx, now); err != nil {
+		return err
+	}
+
+	ls.mu.Lock()
+	start := ls.nextWindow
+	ls.mu.Unlock()
+	if start.IsZero() {
+		return nil
+	}
+
+	slog.Info("Aggregating final partial window", "window_start", start, "until", now.UTC())
+	return ls.aggregateWindow(ctx, start, now.Add(time.Second))
+}
+
+// catchUp aggregates every complete window between nextWindow and now. It
+// stops at the first window that fails, so that window is retried next time.
+func (ls *ServiceCom) catchUp(ctx context.Context, now time.Time) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	end := windowStart(now)
+	if ls.nextWindow.IsZero() {
+		ls.nextWindow = end
+	}
+	oldest := ls.oldestWindow(now)
+	if ls.nextWindow.Before(oldest) {
+		slog.Warn("Windows older than tick retention cannot be aggregated",
+			"from", ls.nextWindow, "to", oldest, "retention", ls.retention)
+		ls.nextWindow = oldest
+	}
+
+	if missed := int(end.Sub(ls.nextWindow)/Window) - 1; missed > 0 {
+		slog.Warn("Backfilling missed aggregation windows", "windows", missed, "from", ls.nextWindow)
+	}
+	for w := ls.nextWindow; w.Before(end); w = w.Add(Window) {
+		if err := ls.aggregateWindow(ctx, w, w.Add(Window)); err != nil {
+			slog.Error("Aggregation window failed, will retry", "window_start", w, "err", err)
+			return err
+		}
+		ls.nextWindow = w.Add(Window)
+	}
+	return nil
+}
+
+// oldestWindow is the start of the oldest window whose ticks are all still
+// within the tick retention at now.
+func (ls *ServiceCom) oldestWindow(now time.Time) time.Time {
+	return windowStart(now.Add(-ls.retention)).Add(Window)
+}
+
+// Reaggregate recomputes every window starting in [from, to) from the ticks
+// still in Redis, replacing the stored rows, and returns how many windows it
+// processed. Windows older than the tick retention are skipped with a
+// warning; a range that lies entirely before it is an invalid argument.
+func (ls *ServiceCom) Reaggregate(ctx context.Context, from, to time.Time) (int, error) {
+	now := time.Now()
+	start, end := windowStart(from), windowStart(to)
+	if !to.Equal(end) {
+		end = end.Add(Window)
+	}
+	if current := windowStart(now); end.After(current) {
+		end = current
+	}
+	if oldest := ls.oldestWindow(now); start.Before(oldest) {
+		if !end.After(oldest) {
+			return 0, domain.NewInvalidArgument("range_out_of_retention", fmt.Sprintf(
+				"ticks are kept for %s, so windows before %s cannot be aggregated", ls.retention, oldest.Format(time.RFC3339)))
+		}
+		slog.Warn("Windows older than tick retention cannot be aggregated",
+			"from", start, "to", oldest, "retention", ls.retention)
+		start = oldest
+	}
+
+	windows := 0
+	for w := start; w.Before(end); w = w.Add(Window) {
+		if err := ls.aggregateWindow(ctx, w, w.Add(Window)); err != nil {
+			return windows, fmt.Errorf("window %s: %w", w.Format(time.RFC3339), err)
+		}
+		windows++
+	}
+	return windows, nil
+}
+
+// aggregateWindow saves one row per series from the Redis ticks scored in
+// [start, end), stamped with the window start.
+func (ls *ServiceCom) aggregateWindow(ctx context.Context, start, end time.Time) error {
+	began := time.Now()
+	rows := 0
+	var errs []error
+
+	for _, pair := range ls.registry.Symbols() {
+		for _, ex := range ls.registry.Exchanges() {
+			key := domain.PriceKey(pair, ex)
+			// Scores are whole seconds, so end-1 makes the range half-open.
+			values, err := ls.redisRepo.ZRangeByScore(ctx, key, start.Unix(), end.Unix()-1)
+			if err != nil {
+				slog.Error("Failed to get prices from Redis", "key", key, "err", err)
+				errs = append(errs, err)
+				continue
+			}
+			if len(values) == 0 {
+				slog.Debug("No prices found in Redis", "key", key)
+				continue
+			}
+
+			var prices []float64
+			for _, v := range values {
+				price, err := tickPrice(v)
+				if err != nil {
+					slog.Warn("Failed to parse price from Redis", "value", v, "err", err)
+					continue
+				}
+				prices = append(prices, price)
+			}
+
+			if len(prices) == 0 {
+				slog.Debug("No valid prices parsed for aggregation", "key", key)
+				continue
+			}
+
+			min, max, avg := CalcStats(prices)
+			err = ls.pgSave.SaveAggregatedPrice(ctx, pair, ex, start, avg, min, max)
+			if err != nil {
+				slog.Error("Failed to save aggregated price to DB", "pair", pair, "exchange", ex, "err", err)
+				errs = append(errs, err)
+				continue
+			}
+			metrics.AggregatorRowsWritten.Inc()
+			rows++
+			slog.Debug("Aggregated price saved",
+				"pair", pair,
+				"exchange", ex,
+				"window_start", start,
+				"avg", avg,
+				"min", min,
+				"max", max,
+			)
+			if ls.publisher != nil {
+				ls.publisher.PublishAggregate(domain.AggregatedResponse{
+					Pair:      pair,
+					Exchange:  ex,
+					Timestamp: start.Format(time.RFC3339),
+					Avg:       avg,
+					Min:       min,
+					Max:       max,
+				})
+			}
+		}
+	}
+
+	metrics.AggregatorCycleDuration.ObserveSince(began)
+	slog.Info("Aggregation window complete", "window_start", start, "rows", rows, "duration", time.Since(began))
+	return errors.Join(errs...)
+}
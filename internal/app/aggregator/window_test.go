@@ -0,0 +1,170 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/domain"
+)
+
+func newTestService(retention time.Duration) (*ServiceCom, *memory.SortedSets, *memory.Store) {
+	ticks, store := memory.NewSortedSets(), memory.NewStore()
+	registry := domain.NewRegistry([]string{"exchange1"}, []string{"BTCUSDT"})
+	return NewServiceCom(ticks, store, registry, nil, retention), ticks, store
+}
+
+// rows returns the stored minute aggregates of BTCUSDT on exchange1.
+func rows(t *testing.T, store *memory.Store) []domain.HistoryRecord {
+	t.Helper()
+	records, err := store.GetHistory(context.Background(), domain.HistoryQuery{Symbol: "BTCUSDT", Exchange: "exchange1", Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestWindowStart(t *testing.T) {
+	tests := []struct{ in, want time.Time }{
+		{time.Date(2024, 1, 1, 12, 34, 56, 789, time.UTC), time.Date(2024, 1, 1, 12, 34, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 1, 12, 34, 0, 0, time.UTC), time.Date(2024, 1, 1, 12, 34, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 1, 23, 59, 59, 999999999, time.UTC), time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC)},
+		// Zones with half-hour offsets still align to UTC minutes.
+		{time.Date(2024, 1, 1, 5, 30, 10, 0, time.FixedZone("IST", 5*3600+1800)), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := windowStart(tt.in); !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("windowStart(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWorkersKeepRepeatedPrices(t *testing.T) {
+	ls, ticks, store := newTestService(0)
+	ctx := context.Background()
+	input := make(chan domain.PriceUpdate, 4)
+	for _, p := range []float64{10, 10, 20, 10} {
+		input <- domain.PriceUpdate{Symbol: "BTCUSDT", Exchange: "exchange1", Price: p}
+	}
+	close(input)
+
+	start := windowStart(time.Now())
+	ls.StartRedisWorkerPool(ctx, input, 2)
+	if err := ls.WaitWorkers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	members, _ := ticks.ZRangeByScore(ctx, domain.PriceKey("BTCUSDT", "exchange1"), 0, time.Now().Unix()+1)
+	if len(members) != 4 {
+		t.Fatalf("stored %d ticks (%q), want 4", len(members), members)
+	}
+
+	if err := ls.aggregateWindow(ctx, start, time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	got := rows(t, store)
+	if len(got) != 1 {
+		t.Fatalf("saved %d rows, want 1", len(got))
+	}
+	if p := got[0].Price; p.Avg != 12.5 || p.Min != 10 || p.Max != 20 {
+		t.Errorf("aggregate = %+v, want avg 12.5, min 10, max 20", p)
+	}
+}
+
+func TestCatchUpAlignsAndBackfills(t *testing.T) {
+	ls, ticks, store := newTestService(15 * time.Minute)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	key := domain.PriceKey("BTCUSDT", "exchange1")
+
+	add := func(at time.Time, price float64) {
+		t.Helper()
+		if err := ticks.ZAdd(ctx, key, at.Unix(), ls.tickMember(price, at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 11:57 has a tick on each boundary; its last second belongs to it and
+	// 11:58:00 to the next window. A bare price as stored by earlier
+	// versions is still read. 12:00 is still open and must not be written.
+	add(time.Date(2024, 1, 1, 11, 57, 0, 0, time.UTC), 1)
+	add(time.Date(2024, 1, 1, 11, 57, 59, 0, time.UTC), 3)
+	add(time.Date(2024, 1, 1, 11, 58, 0, 0, time.UTC), 7)
+	ticks.ZAdd(ctx, key, time.Date(2024, 1, 1, 11, 59, 10, 0, time.UTC).Unix(), "9")
+	add(time.Date(2024, 1, 1, 12, 0, 5, 0, time.UTC), 100)
+
+	ls.nextWindow = time.Date(2024, 1, 1, 11, 57, 0, 0, time.UTC)
+	if err := ls.catchUp(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if want := windowStart(now); !ls.nextWindow.Equal(want) {
+		t.Errorf("nextWindow = %v, want %v", ls.nextWindow, want)
+	}
+
+	want := []struct {
+		start    string
+		avg      float64
+		min, max float64
+	}{
+		{"2024-01-01T11:57:00Z", 2, 1, 3},
+		{"2024-01-01T11:58:00Z", 7, 7, 7},
+		{"2024-01-01T11:59:00Z", 9, 9, 9},
+	}
+	got := rows(t, store)
+	if len(got) != len(want) {
+		t.Fatalf("saved %d rows, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if p := got[i].Price; p.Timestamp != w.start || p.Avg != w.avg || p.Min != w.min || p.Max != w.max {
+			t.Errorf("row %d = %+v, want %+v", i, p, w)
+		}
+	}
+
+	// A late tick in an aggregated window is picked up when the window is
+	// redone, replacing its row rather than adding one.
+	add(time.Date(2024, 1, 1, 11, 58, 30, 0, time.UTC), 7)
+	add(time.Date(2024, 1, 1, 11, 58, 31, 0, time.UTC), 10)
+	ls.nextWindow = time.Date(2024, 1, 1, 11, 58, 0, 0, time.UTC)
+	if err := ls.catchUp(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	got = rows(t, store)
+	if len(got) != 3 || got[1].Price.Avg != 8 || got[1].Price.Max != 10 {
+		t.Errorf("rows after redo = %+v, want 3 with 11:58 averaging 8", got)
+	}
+}
+
+func TestCatchUpSkipsWindowsPastRetention(t *testing.T) {
+	ls, _, _ := newTestService(5 * time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	ls.nextWindow = now.Add(-time.Hour)
+	if err := ls.catchUp(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	if want := windowStart(now); !ls.nextWindow.Equal(want) {
+		t.Errorf("nextWindow = %v, want %v", ls.nextWindow, want)
+	}
+	if got := ls.oldestWindow(now); !got.Equal(time.Date(2024, 1, 1, 11, 56, 0, 0, time.UTC)) {
+		t.Errorf("oldestWindow = %v, want 11:56", got)
+	}
+}
+
+func TestReaggregateRejectsRangePastRetention(t *testing.T) {
+	ls, _, _ := newTestService(5 * time.Minute)
+	to := time.Now().Add(-time.Hour)
+	_, err := ls.Reaggregate(context.Background(), to.Add(-time.Hour), to)
+	if !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("Reaggregate = %v, want invalid argument", err)
+	}
+}
+
+func TestTickPrice(t *testing.T) {
+	for member, want := range map[string]float64{"42.5": 42.5, "42.5:1704110400000000000:7": 42.5} {
+		if got, err := tickPrice(member); err != nil || got != want {
+			t.Errorf("tickPrice(%q) = %v, %v; want %v", member, got, err, want)
+		}
+	}
+	if _, err := tickPrice("abc:1:2"); err == nil {
+		t.Error("tickPrice accepted a member without a price")
+	}
+}
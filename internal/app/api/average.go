@@ -1,89 +1,106 @@
 package api
 
 import (
-	"errors"
+	"context"
 	"log/slog"
-	"strings"
-	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (s *APIService) GetAvgBySymbol(symbol string) (*domain.AggregatedResponse, error) {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-	if symbol == "" {
-		slog.Warn("GetAvgBySymbol: symbol is empty")
-		return nil, errors.New("symbol cannot be empty")
+func (s *APIService) GetAvgBySymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetAvgBySymbol: invalid symbol", "err", err)
+		return nil, err
 	}
 
-	slog.Info("GetAvgBySymbol called", "symbol", symbol)
-	data, err := s.repo.GetAvgBySymbol(symbol)
+	slog.DebugContext(ctx, "GetAvgBySymbol called", "symbol", symbol)
+	data, err := s.repo.QueryAvgInRange(ctx, symbol, s.withResolution(domain.TimeRange{}))
 	if err != nil {
-		slog.Error("GetAvgBySymbol failed", "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetAvgBySymbol failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("GetAvgBySymbol: no data found", "symbol", symbol)
-		return nil, errors.New("no data found for symbol: " + symbol)
+		slog.DebugContext(ctx, "GetAvgBySymbol: no data found", "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetAvgBySymbol success", "symbol", symbol, "avg", data.Avg)
+	slog.DebugContext(ctx, "GetAvgBySymbol success", "symbol", symbol, "avg", data.Avg)
 	return data, nil
 }
 
-func (s *APIService) GetAvgByExchange(path string) (*domain.AggregatedResponse, error) {
-	slog.Info("GetAvgByExchange called", "path", path)
+func (s *APIService) GetAvgByExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error) {
+	slog.DebugContext(ctx, "GetAvgByExchange called", "exchange", exchange, "symbol", symbol)
 
-	parts := strings.Split(strings.Trim(path, "/"), "/")
-	if len(parts) != 2 {
-		slog.Warn("GetAvgByExchange: invalid path", "path", path)
-		return nil, errors.New("invalid path format: expected /exchange/symbol")
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetAvgByExchange: invalid exchange or symbol", "err", err)
+		return nil, err
 	}
 
-	exchange := strings.TrimSpace(parts[0])
-	symbol := strings.ToUpper(strings.TrimSpace(parts[1]))
-
-	if exchange == "" || symbol == "" {
-		slog.Warn("GetAvgByExchange: exchange or symbol is empty", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("exchange and symbol must not be empty")
+	data, err := s.repo.QueryAvgInRangeByExchange(ctx, exchange, symbol, s.withResolution(domain.TimeRange{}))
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetAvgByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
+	}
+	if data == nil {
+		slog.DebugContext(ctx, "GetAvgByExchange: no data found", "exchange", exchange, "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol).WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	data, err := s.repo.GetAvgByExchange(exchange, symbol)
+	slog.DebugContext(ctx, "GetAvgByExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
+	return data, nil
+}
+
+func (s *APIService) GetAvgInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
 	if err != nil {
-		slog.Error("GetAvgByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		slog.DebugContext(ctx, "GetAvgInRange: invalid symbol", "err", err)
 		return nil, err
 	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetAvgInRange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "GetAvgInRange called", "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryAvgInRange(ctx, symbol, s.withResolution(rng))
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetAvgInRange failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
+	}
 	if data == nil {
-		slog.Warn("GetAvgByExchange: no data found", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("no data found for exchange/symbol: " + exchange + "/" + symbol)
+		slog.DebugContext(ctx, "GetAvgInRange: no data found", "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol+" in range").WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetAvgByExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
+	slog.DebugContext(ctx, "GetAvgInRange success", "symbol", symbol, "avg", data.Avg)
 	return data, nil
 }
 
-func (s *APIService) QueryAvgSinceByExchange(exchange, symbol string, period time.Duration) (*domain.AggregatedResponse, error) {
-	slog.Info("QueryAvgSinceByExchange called", "exchange", exchange, "symbol", symbol, "period", period)
-
-	exchange = strings.TrimSpace(exchange)
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-
-	if exchange == "" || symbol == "" {
-		slog.Warn("QueryAvgSinceByExchange: exchange or symbol is empty")
-		return nil, errors.New("exchange and symbol must not be empty")
+func (s *APIService) GetAvgInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetAvgInRangeByExchange: invalid exchange or symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetAvgInRangeByExchange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
 	}
 
-	since := time.Now().Add(-period)
-	data, err := s.repo.QueryAvgSinceByExchange(exchange, symbol, since)
+	slog.DebugContext(ctx, "GetAvgInRangeByExchange called", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryAvgInRangeByExchange(ctx, exchange, symbol, s.withResolution(rng))
 	if err != nil {
-		slog.Error("QueryAvgSinceByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetAvgInRangeByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("QueryAvgSinceByExchange: no data found", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("no data found for exchange/symbol in given period")
+		slog.DebugContext(ctx, "GetAvgInRangeByExchange: no data found", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol+" in range").WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("QueryAvgSinceByExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
+	slog.DebugContext(ctx, "GetAvgInRangeByExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
 	return data, nil
 }
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+const MaxBatchItems = 100
+
+type batchGroup struct {
+	stat    string
+	rng     domain.TimeRange
+	keys    []domain.SeriesKey
+	indexes []int
+}
+
+// QueryBatch answers many statistic queries at once. Items are validated
+// individually, then grouped by statistic and range so each group costs a
+// single repository call. Failures are reported per item.
+func (s *APIService) QueryBatch(ctx context.Context, items []domain.BatchItem) ([]domain.BatchResult, error) {
+	if len(items) == 0 {
+		slog.DebugContext(ctx, "QueryBatch: no items")
+		return nil, domain.NewInvalidArgument("batch_empty", "at least one query item is required")
+	}
+	if len(items) > MaxBatchItems {
+		slog.DebugContext(ctx, "QueryBatch: too many items", "items", len(items))
+		return nil, domain.NewInvalidArgument("batch_too_large", "at most "+strconv.Itoa(MaxBatchItems)+" query items are allowed").
+			WithDetail("max_items", MaxBatchItems)
+	}
+
+	slog.DebugContext(ctx, "QueryBatch called", "items", len(items))
+	now := time.Now()
+	results := make([]domain.BatchResult, len(items))
+	groups := make(map[string]*batchGroup)
+	var order []string
+
+	for i, item := range items {
+		results[i].Item = item
+
+		key, rng, err := s.resolveBatchItem(item, now)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Item.Symbol, results[i].Item.Exchange = key.Symbol, key.Exchange
+
+		if item.Stat != domain.StatLatest {
+			rng = s.withResolution(rng)
+		}
+		groupKey := item.Stat + "|" + rng.From.String() + "|" + rng.To.String() + "|" + rng.Resolution.String()
+		g, ok := groups[groupKey]
+		if !ok {
+			g = &batchGroup{stat: item.Stat, rng: rng}
+			groups[groupKey] = g
+			order = append(order, groupKey)
+		}
+		g.keys = append(g.keys, key)
+		g.indexes = append(g.indexes, i)
+	}
+
+	for _, groupKey := range order {
+		g := groups[groupKey]
+		found, err := s.repo.QueryBatch(ctx, g.stat, g.rng, g.keys)
+		if err != nil {
+			slog.Log(ctx, logging.ErrorLevel(ctx, err), "QueryBatch group failed", "stat", g.stat, "series", len(g.keys), "err", err)
+			for _, i := range g.indexes {
+				results[i].Err = storageError(ctx, err)
+			}
+			continue
+		}
+		for n, i := range g.indexes {
+			key := g.keys[n]
+			if data, ok := found[key]; ok {
+				results[i].Data = data
+				continue
+			}
+			nf := domain.NewNotFound("no_data", "no data found for "+g.stat+" "+key.Symbol).WithDetail("symbol", key.Symbol)
+			if key.Exchange != "" {
+				nf.WithDetail("exchange", key.Exchange)
+			}
+			results[i].Err = nf
+		}
+	}
+
+	slog.DebugContext(ctx, "QueryBatch success", "items", len(items), "groups", len(order))
+	return results, nil
+}
+
+func (s *APIService) resolveBatchItem(item domain.BatchItem, now time.Time) (domain.SeriesKey, domain.TimeRange, error) {
+	switch item.Stat {
+	case domain.StatLatest, domain.StatHighest, domain.StatLowest, domain.StatAverage:
+	default:
+		return domain.SeriesKey{}, domain.TimeRange{}, domain.NewInvalidArgument("invalid_stat", "unknown stat: "+item.Stat).
+			WithDetail("valid_stats", []string{domain.StatLatest, domain.StatHighest, domain.StatLowest, domain.StatAverage})
+	}
+
+	var key domain.SeriesKey
+	var err error
+	if key.Symbol, err = s.registry.Symbol(item.Symbol); err != nil {
+		return domain.SeriesKey{}, domain.TimeRange{}, err
+	}
+	if item.Exchange != "" {
+		if key.Exchange, err = s.registry.Exchange(item.Exchange); err != nil {
+			return domain.SeriesKey{}, domain.TimeRange{}, err
+		}
+	}
+
+	rng, err := domain.ResolveTimeRange(item.Period, item.From, item.To, now)
+	if err != nil {
+		return domain.SeriesKey{}, domain.TimeRange{}, err
+	}
+	return key, rng, nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+func (s *APIService) ListExchanges() []string {
+	return s.registry.Exchanges()
+}
+
+func (s *APIService) ListSymbols() []string {
+	return s.registry.Symbols()
+}
+
+// GetCoverage reports, for every registered exchange/symbol pair and every
+// pair present in storage, the stored aggregate range and the latest tick
+// still held in Redis.
+func (s *APIService) GetCoverage(ctx context.Context) ([]domain.Coverage, error) {
+	slog.DebugContext(ctx, "GetCoverage called")
+
+	stored, err := s.repo.GetCoverage(ctx)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetCoverage failed", "err", err)
+		return nil, storageError(ctx, err)
+	}
+
+	byKey := make(map[string]domain.Coverage, len(stored))
+	for _, c := range stored {
+		byKey[c.Exchange+"/"+c.Symbol] = c
+	}
+
+	coverage := make([]domain.Coverage, 0, len(stored))
+	for _, ex := range s.registry.Exchanges() {
+		for _, sym := range s.registry.Symbols() {
+			key := ex + "/" + sym
+			c, ok := byKey[key]
+			if !ok {
+				c = domain.Coverage{Exchange: ex, Symbol: sym}
+			}
+			delete(byKey, key)
+			coverage = append(coverage, c)
+		}
+	}
+	for _, c := range stored {
+		if _, ok := byKey[c.Exchange+"/"+c.Symbol]; ok {
+			coverage = append(coverage, c)
+		}
+	}
+
+	for i := range coverage {
+		key := domain.PriceKey(coverage[i].Symbol, coverage[i].Exchange)
+		score, ok, err := s.ticks.ZMaxScore(ctx, key)
+		if err != nil {
+			slog.DebugContext(ctx, "GetCoverage: latest tick lookup failed", "key", key, "err", err)
+			continue
+		}
+		if ok {
+			coverage[i].LatestTick = time.Unix(score, 0).UTC().Format(time.RFC3339)
+		}
+	}
+
+	slog.DebugContext(ctx, "GetCoverage success", "series", len(coverage))
+	return coverage, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+// MaxExportRows caps a single export; larger ranges are exported in parts.
+const MaxExportRows = 1000000
+
+// errExportLimit stops the stream at the first row past the export limit.
+var errExportLimit = errors.New("export limit reached")
+
+// ExportAggregates streams the stored aggregates of a pair over rng to fn in
+// time order, at most limit rows (zero or more than MaxExportRows means
+// MaxExportRows). resolution names a stored resolution (1m, 5m, 1h or 1d;
+// empty means 1m), and every row carries it as its Interval. The range is
+// not counted up front: the export stops at the limit and reports whether
+// rows were left out.
+func (s *APIService) ExportAggregates(ctx context.Context, exchange, symbol string, rng domain.TimeRange, resolution string, limit int, fn func(domain.AggregatedResponse) error) (bool, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "ExportAggregates: invalid symbol", "err", err)
+		return false, err
+	}
+	if exchange != "" {
+		if exchange, err = s.registry.Exchange(exchange); err != nil {
+			slog.DebugContext(ctx, "ExportAggregates: invalid exchange", "err", err)
+			return false, err
+		}
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "ExportAggregates: invalid time range", "from", rng.From, "to", rng.To)
+		return false, err
+	}
+	if rng.Resolution, err = s.resolution(resolution); err != nil {
+		return false, err
+	}
+	if resolution == "" {
+		resolution = "1m"
+	}
+	if limit <= 0 || limit > MaxExportRows {
+		limit = MaxExportRows
+	}
+
+	// One row past the limit tells a truncated export from one that ends
+	// exactly at it.
+	q := domain.ExportQuery{Exchange: exchange, Symbol: symbol, Range: rng, Limit: limit + 1}
+	slog.DebugContext(ctx, "ExportAggregates called", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To, "limit", limit)
+	sent := 0
+	err = s.repo.StreamAggregates(ctx, q, func(p domain.AggregatedResponse) error {
+		if sent == limit {
+			return errExportLimit
+		}
+		sent++
+		p.Interval = resolution
+		return fn(p)
+	})
+	if errors.Is(err, errExportLimit) {
+		slog.DebugContext(ctx, "ExportAggregates: limit reached", "symbol", symbol, "rows", sent)
+		return true, nil
+	}
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "ExportAggregates stream failed", "symbol", symbol, "rows", sent, "err", err)
+		return false, storageError(ctx, err)
+	}
+	return false, nil
+}
+
+// resolution looks up a stored resolution by name; empty means one minute.
+func (s *APIService) resolution(name string) (time.Duration, error) {
+	if name == "" {
+		return time.Minute, nil
+	}
+	names := make([]string, 0, len(s.resolutions))
+	for _, r := range s.resolutions {
+		if r.Name == name {
+			return r.Interval, nil
+		}
+		names = append(names, r.Name)
+	}
+	return 0, domain.NewInvalidArgument("invalid_resolution", "unknown resolution: "+name).
+		WithDetail("parameter", "resolution").WithDetail("valid_resolutions", names)
+}
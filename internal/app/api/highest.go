@@ -1,114 +1,106 @@
 package api
 
 import (
-	"errors"
+	"context"
 	"log/slog"
-	"strings"
-	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (s *APIService) GetHighestBySymbol(symbol string) (*domain.AggregatedResponse, error) {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-
-	if symbol == "" {
-		slog.Warn("GetHighestBySymbol: empty symbol")
-		return nil, errors.New("symbol cannot be empty")
+func (s *APIService) GetHighestBySymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetHighestBySymbol: invalid symbol", "err", err)
+		return nil, err
 	}
 
-	slog.Info("GetHighestBySymbol called", "symbol", symbol)
-	data, err := s.repo.GetHighestBySymbol(symbol)
+	slog.DebugContext(ctx, "GetHighestBySymbol called", "symbol", symbol)
+	data, err := s.repo.QueryHighestInRange(ctx, symbol, s.withResolution(domain.TimeRange{}))
 	if err != nil {
-		slog.Error("GetHighestBySymbol failed", "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetHighestBySymbol failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("GetHighestBySymbol: no data", "symbol", symbol)
-		return nil, errors.New("no data found for symbol: " + symbol)
+		slog.DebugContext(ctx, "GetHighestBySymbol: no data", "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetHighestBySymbol success", "symbol", symbol, "max", data.Max)
+	slog.DebugContext(ctx, "GetHighestBySymbol success", "symbol", symbol, "max", data.Max)
 	return data, nil
 }
 
-func (s *APIService) GetHighestByExchange(path string) (*domain.AggregatedResponse, error) {
-	slog.Info("GetHighestByExchange called", "path", path)
-
-	parts := strings.Split(strings.Trim(path, "/"), "/")
-	if len(parts) != 2 {
-		slog.Warn("GetHighestByExchange: invalid path format", "path", path)
-		return nil, errors.New("invalid path format: expected /exchange/symbol")
-	}
-
-	exchange := strings.TrimSpace(parts[0])
-	symbol := strings.ToUpper(strings.TrimSpace(parts[1]))
+func (s *APIService) GetHighestByExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error) {
+	slog.DebugContext(ctx, "GetHighestByExchange called", "exchange", exchange, "symbol", symbol)
 
-	if exchange == "" || symbol == "" {
-		slog.Warn("GetHighestByExchange: exchange or symbol is empty", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("exchange and symbol must not be empty")
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetHighestByExchange: invalid exchange or symbol", "err", err)
+		return nil, err
 	}
 
-	data, err := s.repo.GetHighestByExchange(exchange, symbol)
+	data, err := s.repo.QueryHighestInRangeByExchange(ctx, exchange, symbol, s.withResolution(domain.TimeRange{}))
 	if err != nil {
-		slog.Error("GetHighestByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetHighestByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("GetHighestByExchange: no data", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("no data found for exchange/symbol: " + exchange + "/" + symbol)
+		slog.DebugContext(ctx, "GetHighestByExchange: no data", "exchange", exchange, "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol).WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetHighestByExchange success", "exchange", exchange, "symbol", symbol, "max", data.Max)
+	slog.DebugContext(ctx, "GetHighestByExchange success", "exchange", exchange, "symbol", symbol, "max", data.Max)
 	return data, nil
 }
 
-func (s *APIService) GetHighestByPeriod(symbol string, period time.Duration) (*domain.AggregatedResponse, error) {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-	if symbol == "" {
-		slog.Warn("GetHighestByPeriod: empty symbol")
-		return nil, errors.New("symbol cannot be empty")
+func (s *APIService) GetHighestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetHighestInRange: invalid symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetHighestInRange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
 	}
 
-	since := time.Now().Add(-period)
-	slog.Info("GetHighestByPeriod called", "symbol", symbol, "since", since)
-
-	data, err := s.repo.QueryHighestPriceSince(symbol, since)
+	slog.DebugContext(ctx, "GetHighestInRange called", "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryHighestInRange(ctx, symbol, s.withResolution(rng))
 	if err != nil {
-		slog.Error("GetHighestByPeriod failed", "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetHighestInRange failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("GetHighestByPeriod: no data", "symbol", symbol)
-		return nil, errors.New("no data found for symbol in period")
+		slog.DebugContext(ctx, "GetHighestInRange: no data found", "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol+" in range").WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetHighestByPeriod success", "symbol", symbol, "max", data.Max)
+	slog.DebugContext(ctx, "GetHighestInRange success", "symbol", symbol, "max", data.Max)
 	return data, nil
 }
 
-func (s *APIService) QueryHighestSinceByExchange(exchange, symbol string, period time.Duration) (*domain.AggregatedResponse, error) {
-	exchange = strings.TrimSpace(exchange)
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-
-	if exchange == "" || symbol == "" {
-		slog.Warn("QueryHighestSinceByExchange: empty exchange or symbol", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("exchange and symbol must not be empty")
+func (s *APIService) GetHighestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetHighestInRangeByExchange: invalid exchange or symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetHighestInRangeByExchange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
 	}
 
-	since := time.Now().Add(-period)
-	slog.Info("QueryHighestSinceByExchange called", "exchange", exchange, "symbol", symbol, "since", since)
-
-	data, err := s.repo.QueryHighestSinceByExchange(exchange, symbol, since)
+	slog.DebugContext(ctx, "GetHighestInRangeByExchange called", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryHighestInRangeByExchange(ctx, exchange, symbol, s.withResolution(rng))
 	if err != nil {
-		slog.Error("QueryHighestSinceByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetHighestInRangeByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("QueryHighestSinceByExchange: no data", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("no data found for exchange/symbol in period")
+		slog.DebugContext(ctx, "GetHighestInRangeByExchange: no data found", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol+" in range").WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("QueryHighestSinceByExchange success", "exchange", exchange, "symbol", symbol, "max", data.Max)
+	slog.DebugContext(ctx, "GetHighestInRangeByExchange success", "exchange", exchange, "symbol", symbol, "max", data.Max)
 	return data, nil
 }
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 1000
+)
+
+func (s *APIService) GetHistory(ctx context.Context, exchange, symbol string, rng domain.TimeRange, limit int, cursor string) (*domain.HistoryPage, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetHistory: invalid symbol", "err", err)
+		return nil, err
+	}
+	if exchange != "" {
+		if exchange, err = s.registry.Exchange(exchange); err != nil {
+			slog.DebugContext(ctx, "GetHistory: invalid exchange", "err", err)
+			return nil, err
+		}
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetHistory: invalid time range", "from", rng.From, "to", rng.To)
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+
+	q := domain.HistoryQuery{
+		Exchange: exchange,
+		Symbol:   symbol,
+		Range:    rng,
+		Limit:    limit + 1,
+	}
+	if cursor != "" {
+		afterTS, afterID, err := decodeCursor(cursor)
+		if err != nil {
+			slog.DebugContext(ctx, "GetHistory: invalid cursor", "cursor", cursor, "err", err)
+			return nil, err
+		}
+		q.AfterTS, q.AfterID = afterTS, afterID
+	}
+
+	slog.DebugContext(ctx, "GetHistory called", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To, "limit", limit)
+	records, err := s.repo.GetHistory(ctx, q)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetHistory failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
+	}
+
+	page := &domain.HistoryPage{Items: make([]domain.AggregatedResponse, 0, len(records))}
+	if len(records) > limit {
+		records = records[:limit]
+		last := records[len(records)-1]
+		page.NextCursor = encodeCursor(last.Timestamp, last.ID)
+	}
+	for _, rec := range records {
+		page.Items = append(page.Items, rec.Price)
+	}
+
+	slog.DebugContext(ctx, "GetHistory success", "exchange", exchange, "symbol", symbol, "items", len(page.Items))
+	return page, nil
+}
+
+// encodeCursor packs the (timestamp, id) keyset position of the last row of a
+// page into an opaque token, so the next page starts strictly after it even
+// when several rows share a timestamp.
+func encodeCursor(ts time.Time, id int64) string {
+	raw := strconv.FormatInt(ts.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, domain.NewInvalidArgument("invalid_cursor", "invalid cursor")
+	}
+	tsPart, idPart, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, domain.NewInvalidArgument("invalid_cursor", "invalid cursor")
+	}
+	nanos, err := strconv.ParseInt(tsPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, domain.NewInvalidArgument("invalid_cursor", "invalid cursor")
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, domain.NewInvalidArgument("invalid_cursor", "invalid cursor")
+	}
+	return time.Unix(0, nanos).UTC(), id, nil
+}
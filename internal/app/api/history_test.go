@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/domain"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 123, time.UTC)
+	gotTS, gotID, err := decodeCursor(encodeCursor(ts, 42))
+	if err != nil || !gotTS.Equal(ts) || gotID != 42 {
+		t.Errorf("decodeCursor(encodeCursor) = %v, %d, %v; want %v, 42", gotTS, gotID, err, ts)
+	}
+	for _, bad := range []string{"not base64!", "MTIz", "YToxMjM", "MTIzOmI"} {
+		if _, _, err := decodeCursor(bad); !errors.Is(err, domain.ErrInvalidArgument) {
+			t.Errorf("decodeCursor(%q) = %v, want invalid argument", bad, err)
+		}
+	}
+}
+
+func TestGetHistoryPagesWithCursor(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Two exchanges share every window start, so pages split rows with the
+	// same timestamp and only the id keeps the cursor stable.
+	for i := 0; i < 5; i++ {
+		for _, ex := range []string{"exchange2", "exchange1"} {
+			if err := store.SaveAggregatedPrice(ctx, "BTCUSDT", ex, start.Add(time.Duration(i)*time.Minute), float64(i), 0, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	registry := domain.NewRegistry([]string{"exchange1", "exchange2"}, []string{"BTCUSDT"})
+	s := NewService(store, nil, registry, domain.DefaultResolutions())
+	rng := domain.TimeRange{From: start, To: start.Add(time.Hour)}
+
+	var items []domain.AggregatedResponse
+	cursor, pages := "", 0
+	for {
+		page, err := s.GetHistory(ctx, "", "btcusdt", rng, 3, cursor)
+		if err != nil {
+			t.Fatalf("GetHistory page %d: %v", pages, err)
+		}
+		pages++
+		items = append(items, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		if len(page.Items) != 3 {
+			t.Errorf("page %d has %d items before the end, want 3", pages, len(page.Items))
+		}
+		cursor = page.NextCursor
+	}
+
+	if pages != 4 || len(items) != 10 {
+		t.Fatalf("got %d items in %d pages, want 10 in 4", len(items), pages)
+	}
+	seen := map[string]bool{}
+	for i, it := range items {
+		id := it.Timestamp + "/" + it.Exchange
+		if seen[id] {
+			t.Errorf("item %s returned twice", id)
+		}
+		seen[id] = true
+		if want := start.Add(time.Duration(i/2) * time.Minute).Format(time.RFC3339); it.Timestamp != want {
+			t.Errorf("item %d at %s, want %s", i, it.Timestamp, want)
+		}
+	}
+
+	if _, err := s.GetHistory(ctx, "", "BTCUSDT", rng, 3, "garbage"); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("bad cursor: err = %v, want invalid argument", err)
+	}
+}
@@ -1,63 +1,106 @@
 package api
 
 import (
-	"errors"
+	"context"
 	"log/slog"
-	"strings"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (s *APIService) GetAggregatedPriceForSymbol(symbol string) (*domain.AggregatedResponse, error) {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+func (s *APIService) GetAggregatedPriceForSymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetAggregatedPriceForSymbol: invalid symbol", "err", err)
+		return nil, err
+	}
 
-	if symbol == "" {
-		slog.Warn("GetAggregatedPriceForSymbol: symbol is empty")
-		return nil, errors.New("symbol cannot be empty")
+	slog.DebugContext(ctx, "GetAggregatedPriceForSymbol called", "symbol", symbol)
+	data, err := s.repo.GetPriceForSymbol(ctx, symbol)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetPriceForSymbol failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
+	if data == nil {
+		slog.DebugContext(ctx, "GetPriceForSymbol: no data found", "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol).WithDetail("symbol", symbol)
+	}
+
+	slog.DebugContext(ctx, "GetPriceForSymbol success", "symbol", symbol, "avg", data.Avg)
+	return data, nil
+}
 
-	slog.Info("GetAggregatedPriceForSymbol called", "symbol", symbol)
-	data, err := s.repo.GetPriceForSymbol(symbol)
+func (s *APIService) GetAggregatedPriceForExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error) {
+	slog.DebugContext(ctx, "GetAggregatedPriceForExchange called", "exchange", exchange, "symbol", symbol)
+
+	exchange, symbol, err := s.resolve(exchange, symbol)
 	if err != nil {
-		slog.Error("GetPriceForSymbol failed", "symbol", symbol, "err", err)
+		slog.DebugContext(ctx, "GetAggregatedPriceForExchange: invalid exchange or symbol", "err", err)
 		return nil, err
 	}
+
+	data, err := s.repo.GetPriceForExchange(ctx, exchange, symbol)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetPriceForExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
+	}
 	if data == nil {
-		slog.Warn("GetPriceForSymbol: no data found", "symbol", symbol)
-		return nil, errors.New("no data found for symbol: " + symbol)
+		slog.DebugContext(ctx, "GetPriceForExchange: no data", "exchange", exchange, "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol).WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetPriceForSymbol success", "symbol", symbol, "avg", data.Avg)
+	slog.DebugContext(ctx, "GetPriceForExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
 	return data, nil
 }
 
-func (s *APIService) GetAggregatedPriceForExchange(path string) (*domain.AggregatedResponse, error) {
-	slog.Info("GetAggregatedPriceForExchange called", "path", path)
+func (s *APIService) GetLatestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetLatestInRange: invalid symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetLatestInRange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
+	}
 
-	parts := strings.Split(strings.Trim(path, "/"), "/")
-	if len(parts) != 2 {
-		slog.Warn("Invalid path format", "path", path)
-		return nil, errors.New("invalid path format, expected /exchange/symbol")
+	slog.DebugContext(ctx, "GetLatestInRange called", "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryLatestInRange(ctx, symbol, rng)
+	if err != nil {
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetLatestInRange failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
+	}
+	if data == nil {
+		slog.DebugContext(ctx, "GetLatestInRange: no data found", "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol+" in range").WithDetail("symbol", symbol)
 	}
 
-	exchange := strings.TrimSpace(parts[0])
-	symbol := strings.ToUpper(strings.TrimSpace(parts[1]))
+	slog.DebugContext(ctx, "GetLatestInRange success", "symbol", symbol, "avg", data.Avg)
+	return data, nil
+}
 
-	if exchange == "" || symbol == "" {
-		slog.Warn("Exchange or symbol is empty", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("exchange and symbol must not be empty")
+func (s *APIService) GetLatestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetLatestInRangeByExchange: invalid exchange or symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetLatestInRangeByExchange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
 	}
 
-	data, err := s.repo.GetPriceForExchange(exchange, symbol)
+	slog.DebugContext(ctx, "GetLatestInRangeByExchange called", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryLatestInRangeByExchange(ctx, exchange, symbol, rng)
 	if err != nil {
-		slog.Error("GetPriceForExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetLatestInRangeByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("GetPriceForExchange: no data", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("no data found for exchange/symbol: " + exchange + "/" + symbol)
+		slog.DebugContext(ctx, "GetLatestInRangeByExchange: no data found", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol+" in range").WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetPriceForExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
+	slog.DebugContext(ctx, "GetLatestInRangeByExchange success", "exchange", exchange, "symbol", symbol, "avg", data.Avg)
 	return data, nil
 }
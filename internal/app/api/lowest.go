@@ -1,113 +1,106 @@
 package api
 
 import (
-	"errors"
+	"context"
 	"log/slog"
-	"strings"
-	"time"
 
 	"marketflow/internal/domain"
+	"marketflow/internal/logging"
 )
 
-func (s *APIService) GetLowestBySymbol(symbol string) (*domain.AggregatedResponse, error) {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-
-	if symbol == "" {
-		slog.Warn("GetLowestBySymbol: symbol is empty")
-		return nil, errors.New("symbol cannot be empty")
+func (s *APIService) GetLowestBySymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetLowestBySymbol: invalid symbol", "err", err)
+		return nil, err
 	}
 
-	slog.Info("GetLowestBySymbol called", "symbol", symbol)
-	data, err := s.repo.GetLowestBySymbol(symbol)
+	slog.DebugContext(ctx, "GetLowestBySymbol called", "symbol", symbol)
+	data, err := s.repo.QueryLowestInRange(ctx, symbol, s.withResolution(domain.TimeRange{}))
 	if err != nil {
-		slog.Error("GetLowestBySymbol failed", "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetLowestBySymbol failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("No lowest price data found", "symbol", symbol)
-		return nil, errors.New("no data found for symbol: " + symbol)
+		slog.DebugContext(ctx, "No lowest price data found", "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetLowestBySymbol success", "symbol", symbol, "min", data.Min)
+	slog.DebugContext(ctx, "GetLowestBySymbol success", "symbol", symbol, "min", data.Min)
 	return data, nil
 }
 
-func (s *APIService) GetLowestByExchange(path string) (*domain.AggregatedResponse, error) {
-	slog.Info("GetLowestByExchange called", "path", path)
-
-	parts := strings.Split(strings.Trim(path, "/"), "/")
-	if len(parts) != 2 {
-		slog.Warn("Invalid path format", "path", path)
-		return nil, errors.New("invalid path format, expected /exchange/symbol")
-	}
+func (s *APIService) GetLowestByExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error) {
+	slog.DebugContext(ctx, "GetLowestByExchange called", "exchange", exchange, "symbol", symbol)
 
-	exchange := strings.TrimSpace(parts[0])
-	symbol := strings.ToUpper(strings.TrimSpace(parts[1]))
-
-	if exchange == "" || symbol == "" {
-		slog.Warn("Exchange or symbol is empty", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("exchange and symbol must not be empty")
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetAggregatedPriceForExchange: invalid exchange or symbol", "err", err)
+		return nil, err
 	}
 
-	data, err := s.repo.GetLowestByExchange(exchange, symbol)
+	data, err := s.repo.QueryLowestInRangeByExchange(ctx, exchange, symbol, s.withResolution(domain.TimeRange{}))
 	if err != nil {
-		slog.Error("GetLowestByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetLowestByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("No lowest price data found", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("no data found for exchange/symbol: " + exchange + "/" + symbol)
+		slog.DebugContext(ctx, "No lowest price data found", "exchange", exchange, "symbol", symbol)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol).WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetLowestByExchange success", "exchange", exchange, "symbol", symbol, "min", data.Min)
+	slog.DebugContext(ctx, "GetLowestByExchange success", "exchange", exchange, "symbol", symbol, "min", data.Min)
 	return data, nil
 }
 
-func (s *APIService) GetLowestByPeriod(symbol string, period time.Duration) (*domain.AggregatedResponse, error) {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-	if symbol == "" {
-		slog.Warn("GetLowestByPeriod: symbol is empty")
-		return nil, errors.New("symbol cannot be empty")
+func (s *APIService) GetLowestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	symbol, err := s.registry.Symbol(symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetLowestInRange: invalid symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetLowestInRange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
 	}
 
-	since := time.Now().Add(-period)
-	slog.Info("GetLowestByPeriod called", "symbol", symbol, "since", since)
-
-	data, err := s.repo.QueryLowestPriceSince(symbol, since)
+	slog.DebugContext(ctx, "GetLowestInRange called", "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryLowestInRange(ctx, symbol, s.withResolution(rng))
 	if err != nil {
-		slog.Error("QueryLowestPriceSince failed", "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetLowestInRange failed", "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("No lowest price data found for period", "symbol", symbol, "since", since)
-		return nil, errors.New("no data found for symbol: " + symbol + " in period")
+		slog.DebugContext(ctx, "GetLowestInRange: no data found", "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for symbol: "+symbol+" in range").WithDetail("symbol", symbol)
 	}
 
-	slog.Info("GetLowestByPeriod success", "symbol", symbol, "min", data.Min)
+	slog.DebugContext(ctx, "GetLowestInRange success", "symbol", symbol, "min", data.Min)
 	return data, nil
 }
 
-func (s *APIService) QueryLowestSinceByExchange(exchange, symbol string, period time.Duration) (*domain.AggregatedResponse, error) {
-	exchange = strings.TrimSpace(exchange)
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
-	if exchange == "" || symbol == "" {
-		slog.Warn("QueryLowestSinceByExchange: empty values", "exchange", exchange, "symbol", symbol)
-		return nil, errors.New("exchange and symbol must not be empty")
+func (s *APIService) GetLowestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error) {
+	exchange, symbol, err := s.resolve(exchange, symbol)
+	if err != nil {
+		slog.DebugContext(ctx, "GetLowestInRangeByExchange: invalid exchange or symbol", "err", err)
+		return nil, err
+	}
+	if err := rng.Validate(); err != nil {
+		slog.DebugContext(ctx, "GetLowestInRangeByExchange: invalid range", "from", rng.From, "to", rng.To)
+		return nil, err
 	}
 
-	since := time.Now().Add(-period)
-	slog.Info("QueryLowestSinceByExchange called", "exchange", exchange, "symbol", symbol, "since", since)
-
-	data, err := s.repo.QueryLowestSinceByExchange(exchange, symbol, since)
+	slog.DebugContext(ctx, "GetLowestInRangeByExchange called", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+	data, err := s.repo.QueryLowestInRangeByExchange(ctx, exchange, symbol, s.withResolution(rng))
 	if err != nil {
-		slog.Error("QueryLowestSinceByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
-		return nil, err
+		slog.Log(ctx, logging.ErrorLevel(ctx, err), "GetLowestInRangeByExchange failed", "exchange", exchange, "symbol", symbol, "err", err)
+		return nil, storageError(ctx, err)
 	}
 	if data == nil {
-		slog.Warn("No lowest price data found for exchange and period", "exchange", exchange, "symbol", symbol, "since", since)
-		return nil, errors.New("no data found for exchange/symbol: " + exchange + "/" + symbol + " in period")
+		slog.DebugContext(ctx, "GetLowestInRangeByExchange: no data found", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+		return nil, domain.NewNotFound("no_data", "no data found for exchange/symbol: "+exchange+"/"+symbol+" in range").WithDetail("exchange", exchange).WithDetail("symbol", symbol)
 	}
 
-	slog.Info("QueryLowestSinceByExchange success", "exchange", exchange, "symbol", symbol, "min", data.Min)
+	slog.DebugContext(ctx, "GetLowestInRangeByExchange success", "exchange", exchange, "symbol", symbol, "min", data.Min)
 	return data, nil
 }
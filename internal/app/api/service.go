@@ -1,11 +1,84 @@
 package api
 
-import "marketflow/internal/app"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"marketflow/internal/app"
+	"marketflow/internal/domain"
+)
 
 type APIService struct {
-	repo app.APIRepo
+	repo        app.APIRepo
+	ticks       app.RedisRepo
+	registry    *domain.Registry
+	resolutions []domain.Resolution
+}
+
+// NewService builds the query service. resolutions lists the stored rollups
+// from finest to coarsest; statistics are answered from the coarsest one that
+// fits the requested range.
+func NewService(repo app.APIRepo, ticks app.RedisRepo, registry *domain.Registry, resolutions []domain.Resolution) *APIService {
+	return &APIService{repo: repo, ticks: ticks, registry: registry, resolutions: resolutions}
+}
+
+// withResolution sets the resolution a highest, lowest or average query over
+// rng is answered from.
+func (s *APIService) withResolution(rng domain.TimeRange) domain.TimeRange {
+	rng.Resolution = domain.ChooseResolution(s.resolutions, rng, time.Now())
+	return rng
+}
+
+// resolve validates exchange and symbol against the registry and returns
+// their canonical forms.
+func (s *APIService) resolve(exchange, symbol string) (string, string, error) {
+	ex, err := s.registry.Exchange(exchange)
+	if err != nil {
+		return "", "", err
+	}
+	sym, err := s.registry.Symbol(symbol)
+	if err != nil {
+		return "", "", err
+	}
+	return ex, sym, nil
+}
+
+// storageError classifies a repository failure. The context is checked as
+// well as the error, since drivers report a cancelled query with their own
+// error values.
+func storageError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return domain.NewCanceled("request_canceled", "request was canceled", err)
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return domain.NewUnavailable("query_timeout", "query timed out", err)
+	}
+	return domain.NewUnavailable("storage_unavailable", "price storage is unavailable", err)
+}
+
+// ResolveSymbols canonicalises and validates a list of symbols.
+func (s *APIService) ResolveSymbols(symbols []string) ([]string, error) {
+	resolved := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		canonical, err := s.registry.Symbol(sym)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, canonical)
+	}
+	return resolved, nil
 }
 
-func NewService(repo app.APIRepo) *APIService {
-	return &APIService{repo: repo}
+// ResolveExchanges canonicalises and validates a list of exchanges.
+func (s *APIService) ResolveExchanges(exchanges []string) ([]string, error) {
+	resolved := make([]string, 0, len(exchanges))
+	for _, ex := range exchanges {
+		canonical, err := s.registry.Exchange(ex)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, canonical)
+	}
+	return resolved, nil
 }
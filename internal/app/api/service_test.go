@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/app"
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+func TestServiceLogsCarryRequestID(t *testing.T) {
+	registry := domain.NewRegistry([]string{"exchange1"}, []string{"BTCUSDT"})
+	s := NewService(memory.NewStore(), nil, registry, domain.DefaultResolutions())
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	ctx := logging.WithRequestID(context.Background(), "req-42")
+	s.GetHighestBySymbol(ctx, "BTCUSDT")
+	s.GetLowestBySymbol(ctx, "XXX")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("logged %q, want the service's debug lines", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "request_id=req-42") {
+			t.Errorf("log line without the request ID: %s", line)
+		}
+	}
+}
+
+// downRepo fails every highest query as an unreachable database would.
+type downRepo struct {
+	app.APIRepo
+}
+
+func (downRepo) QueryHighestInRange(context.Context, string, domain.TimeRange) (*domain.AggregatedResponse, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestServiceFailureLevels(t *testing.T) {
+	registry := domain.NewRegistry([]string{"exchange1"}, []string{"BTCUSDT"})
+	store := memory.NewStore()
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	tests := []struct {
+		name  string
+		repo  app.APIRepo
+		ctx   context.Context
+		level string
+	}{
+		{"client gone", store, canceled, "level=DEBUG"},
+		{"timed out", store, expired, "level=WARN"},
+		{"storage down", downRepo{store}, context.Background(), "level=ERROR"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		s := NewService(tt.repo, nil, registry, domain.DefaultResolutions())
+		if _, err := s.GetHighestBySymbol(tt.ctx, "BTCUSDT"); err == nil {
+			t.Fatalf("%s: GetHighestBySymbol succeeded", tt.name)
+		}
+		var line string
+		for _, l := range strings.Split(buf.String(), "\n") {
+			if strings.Contains(l, `msg="GetHighestBySymbol failed"`) {
+				line = l
+			}
+		}
+		if !strings.Contains(line, tt.level) {
+			t.Errorf("%s: failure logged as %q, want %s", tt.name, line, tt.level)
+		}
+	}
+}
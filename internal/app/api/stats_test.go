@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/app/rollup"
+	"marketflow/internal/domain"
+)
+
+func TestAllTimeStatsReadRollupsPastMinuteRetention(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	old := now.Truncate(24*time.Hour).AddDate(0, 0, -30)
+	recent := now.Truncate(time.Minute).Add(-time.Hour)
+	save := func(ts time.Time, avg, min, max float64) {
+		t.Helper()
+		if err := store.SaveAggregatedPrice(ctx, "BTCUSDT", "exchange1", ts, avg, min, max); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Three old minutes hold the extremes; two recent ones do not.
+	save(old, 10, 1, 20)
+	save(old.Add(time.Minute), 10, 5, 500)
+	save(old.Add(2*time.Minute), 10, 5, 15)
+	save(recent, 100, 90, 110)
+	save(recent.Add(time.Minute), 100, 90, 110)
+
+	resolutions := domain.DefaultResolutions()
+	if err := rollup.NewJob(store, resolutions).RollUp(ctx, old, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.PruneAggregates(ctx, time.Minute, now.Add(-resolutions[0].Retention)); err != nil || n != 3 {
+		t.Fatalf("PruneAggregates = %d, %v; want the 3 old minute rows", n, err)
+	}
+
+	registry := domain.NewRegistry([]string{"exchange1"}, []string{"BTCUSDT"})
+	s := NewService(store, nil, registry, resolutions)
+
+	high, err := s.GetHighestBySymbol(ctx, "BTCUSDT")
+	if err != nil || high.Max != 500 {
+		t.Errorf("all-time highest = %+v, %v; want 500 from the pruned minutes", high, err)
+	}
+	low, err := s.GetLowestByExchange(ctx, "exchange1", "BTCUSDT")
+	if err != nil || low.Min != 1 {
+		t.Errorf("all-time lowest = %+v, %v; want 1 from the pruned minutes", low, err)
+	}
+	avg, err := s.GetAvgBySymbol(ctx, "BTCUSDT")
+	if want := (3*10.0 + 2*100) / 5; err != nil || math.Abs(avg.Avg-want) > 1e-9 {
+		t.Errorf("all-time average = %+v, %v; want %v over all five minutes", avg, err, want)
+	}
+}
@@ -0,0 +1,214 @@
+package fanout
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
+
+// Policy decides what happens to an update when a subscriber's queue is full.
+type Policy string
+
+const (
+	// PolicyBlock waits for room in the queue, holding back every other
+	// subscriber until it is made.
+	PolicyBlock Policy = "block"
+	// PolicyDropNewest discards the incoming update.
+	PolicyDropNewest Policy = "drop-newest"
+	// PolicyDropOldest discards the oldest queued update to make room.
+	PolicyDropOldest Policy = "drop-oldest"
+)
+
+const DefaultQueueSize = 1000
+
+// Subscription is one consumer of the hub. C is closed when the subscription
+// is removed or the hub stops.
+type Subscription struct {
+	C      <-chan domain.PriceUpdate
+	ch     chan domain.PriceUpdate
+	name   string
+	policy Policy
+	closed bool
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// SubscriberStats is a point-in-time view of one subscription's queue.
+type SubscriberStats struct {
+	Name      string `json:"name"`
+	Policy    Policy `json:"policy"`
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"capacity"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+func (s *Subscription) markDelivered() {
+	s.delivered.Add(1)
+	metrics.FanoutDelivered.Inc(s.name)
+}
+
+func (s *Subscription) markDropped() {
+	s.dropped.Add(1)
+	metrics.FanoutDropped.Inc(s.name)
+}
+
+func (s *Subscription) Stats() SubscriberStats {
+	return SubscriberStats{
+		Name:      s.name,
+		Policy:    s.policy,
+		Queued:    len(s.ch),
+		Capacity:  cap(s.ch),
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+	}
+}
+
+// Hub copies every update read from the ingest channel to each subscriber's
+// own bounded queue, so consumers never compete for messages.
+type Hub struct {
+	mu   sync.RWMutex
+	subs []*Subscription
+
+	received atomic.Uint64
+}
+
+func NewHub() *Hub {
+	return &Hub{}
+}
+
+func (h *Hub) Subscribe(name string, size int, policy Policy) *Subscription {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	ch := make(chan domain.PriceUpdate, size)
+	sub := &Subscription{C: ch, ch: ch, name: name, policy: policy}
+
+	h.mu.Lock()
+	h.subs = append(h.subs, sub)
+	h.mu.Unlock()
+
+	slog.Info("Fan-out subscriber added", "name", name, "queue", size, "policy", policy)
+	return sub
+}
+
+// Unsubscribe removes sub and closes its queue. A PolicyBlock subscriber must
+// keep draining C until Unsubscribe returns.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, s := range h.subs {
+		if s == sub {
+			h.subs = append(h.subs[:i], h.subs[i+1:]...)
+			break
+		}
+	}
+	h.closeLocked(sub)
+	slog.Info("Fan-out subscriber removed", "name", sub.name)
+}
+
+// Run distributes updates from in until in is closed or ctx is done, then
+// closes every subscription so consumers can drain and exit.
+func (h *Hub) Run(ctx context.Context, in <-chan domain.PriceUpdate) {
+	slog.Info("Fan-out hub started")
+	defer h.closeAll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Warn("Fan-out hub context cancelled, stopping...")
+			return
+		case update, ok := <-in:
+			if !ok {
+				slog.Info("Fan-out input closed, stopping hub")
+				return
+			}
+			h.received.Add(1)
+			h.publish(ctx, update)
+		}
+	}
+}
+
+func (h *Hub) publish(ctx context.Context, update domain.PriceUpdate) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.subs {
+		select {
+		case sub.ch <- update:
+			sub.markDelivered()
+			continue
+		default:
+		}
+
+		switch sub.policy {
+		case PolicyBlock:
+			select {
+			case sub.ch <- update:
+				sub.markDelivered()
+			case <-ctx.Done():
+				return
+			}
+		case PolicyDropOldest:
+			// The consumer may empty the queue in between, so both steps are
+			// non-blocking and only an update actually lost is counted.
+			select {
+			case <-sub.ch:
+				sub.markDropped()
+				slog.Debug("Fan-out queue full, oldest update dropped", "subscriber", sub.name, "symbol", update.Symbol, "exchange", update.Exchange)
+			default:
+			}
+			select {
+			case sub.ch <- update:
+				sub.markDelivered()
+			default:
+				sub.markDropped()
+				slog.Debug("Fan-out queue full, update dropped", "subscriber", sub.name, "symbol", update.Symbol, "exchange", update.Exchange)
+			}
+		default:
+			sub.markDropped()
+			slog.Debug("Fan-out queue full, update dropped", "subscriber", sub.name, "symbol", update.Symbol, "exchange", update.Exchange)
+		}
+	}
+}
+
+// Received reports how many updates the hub has read from its input.
+func (h *Hub) Received() uint64 {
+	return h.received.Load()
+}
+
+func (h *Hub) Stats() []SubscriberStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make([]SubscriberStats, 0, len(h.subs))
+	for _, sub := range h.subs {
+		stats = append(stats, sub.Stats())
+	}
+	return stats
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, sub := range h.subs {
+		h.closeLocked(sub)
+	}
+	h.subs = nil
+}
+
+// closeLocked closes sub's queue; the caller holds the write lock, so it can
+// never race with a send made under the read lock.
+func (h *Hub) closeLocked(sub *Subscription) {
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,46 @@
+package fanout
+
+import (
+	"context"
+	"testing"
+
+	"marketflow/internal/domain"
+)
+
+func TestDropOldestCountsOnlyEvictions(t *testing.T) {
+	h := NewHub()
+	sub := h.Subscribe("slow", 2, PolicyDropOldest)
+	ctx := context.Background()
+
+	for _, p := range []float64{1, 2, 3, 4} {
+		h.publish(ctx, domain.PriceUpdate{Symbol: "BTCUSDT", Exchange: "exchange1", Price: p})
+	}
+	if s := sub.Stats(); s.Delivered != 4 || s.Dropped != 2 || s.Queued != 2 {
+		t.Errorf("stats = %+v, want 4 delivered, 2 dropped, 2 queued", s)
+	}
+	for _, want := range []float64{3, 4} {
+		if got := <-sub.C; got.Price != want {
+			t.Errorf("queued price %v, want %v", got.Price, want)
+		}
+	}
+
+	// With room in the queue nothing is evicted.
+	h.publish(ctx, domain.PriceUpdate{Symbol: "BTCUSDT", Exchange: "exchange1", Price: 5})
+	if s := sub.Stats(); s.Dropped != 2 {
+		t.Errorf("dropped = %d after publishing into a free queue, want 2", s.Dropped)
+	}
+}
+
+func TestDropNewestKeepsQueued(t *testing.T) {
+	h := NewHub()
+	sub := h.Subscribe("slow", 1, PolicyDropNewest)
+	for _, p := range []float64{1, 2, 3} {
+		h.publish(context.Background(), domain.PriceUpdate{Price: p})
+	}
+	if s := sub.Stats(); s.Delivered != 1 || s.Dropped != 2 {
+		t.Errorf("stats = %+v, want 1 delivered, 2 dropped", s)
+	}
+	if got := <-sub.C; got.Price != 1 {
+		t.Errorf("queued price %v, want 1", got.Price)
+	}
+}
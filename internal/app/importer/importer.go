@@ -0,0 +1,451 @@
+// Package importer backfills aggregated_prices from files: aggregates as
+// written by the export endpoint, or raw ticks that are aggregated per minute
+// the same way the live aggregator does.
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/app"
+	"marketflow/internal/app/aggregator"
+	"marketflow/internal/app/rollup"
+	"marketflow/internal/domain"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	KindAggregates = "aggregates"
+	KindTicks      = "ticks"
+
+	// maxReportedErrors bounds the row errors kept in a Result.
+	maxReportedErrors = 20
+
+	// progressEvery is how many input rows pass between progress reports.
+	progressEvery = 10000
+)
+
+// Options selects the input format (csv or ndjson) and what its rows are
+// (aggregates or ticks).
+type Options struct {
+	Format string
+	Kind   string
+}
+
+func (o Options) validate() error {
+	if o.Format != FormatCSV && o.Format != FormatNDJSON {
+		return domain.NewInvalidArgument("invalid_parameter", "Invalid 'format', expected csv or ndjson").WithDetail("parameter", "format")
+	}
+	if o.Kind != KindAggregates && o.Kind != KindTicks {
+		return domain.NewInvalidArgument("invalid_parameter", "Invalid 'kind', expected aggregates or ticks").WithDetail("parameter", "kind")
+	}
+	return nil
+}
+
+// Progress counts an import so far. Rows are input rows read; Saved are
+// minute aggregates written; Skipped are invalid rows; Late are ticks more
+// than a minute older than the newest tick read for their symbol and
+// exchange, whose minute has already been written.
+type Progress struct {
+	Rows    int       `json:"rows"`
+	Saved   int       `json:"saved"`
+	Skipped int       `json:"skipped"`
+	Late    int       `json:"late"`
+	From    time.Time `json:"from,omitempty"`
+	To      time.Time `json:"to,omitempty"`
+}
+
+// Result is the final Progress with the first row errors.
+type Result struct {
+	Progress
+	Errors []string `json:"errors,omitempty"`
+}
+
+type Importer struct {
+	save     app.SavePGRepo
+	rollups  *rollup.Job
+	registry *domain.Registry
+}
+
+// NewImporter builds an importer writing through save. When rollups is not
+// nil, the rollups over the imported range are rebuilt afterwards.
+func NewImporter(save app.SavePGRepo, rollups *rollup.Job, registry *domain.Registry) *Importer {
+	return &Importer{save: save, rollups: rollups, registry: registry}
+}
+
+// Import reads r to the end, upserting one row per minute window, and calls
+// progress every few thousand rows when it is not nil. Invalid rows are
+// skipped and reported in the Result; storage errors and cancellation abort
+// the import with a domain error. Importing the same file twice leaves the
+// same rows.
+func (im *Importer) Import(ctx context.Context, r io.Reader, opts Options, progress func(Progress)) (Result, error) {
+	var res Result
+	if err := opts.validate(); err != nil {
+		return res, err
+	}
+
+	rows, err := newRowReader(r, opts)
+	if err != nil {
+		return res, err
+	}
+	run := &importRun{im: im, res: &res, series: make(map[seriesKey]*tickSeries)}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, storageError(ctx, err)
+		}
+		row, line, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		res.Rows++
+		if err == nil {
+			if opts.Kind == KindTicks {
+				err = run.addTick(ctx, row)
+			} else {
+				err = run.addAggregate(ctx, row)
+			}
+		}
+		var storage storageErr
+		if errors.As(err, &storage) {
+			return res, storageError(ctx, storage.err)
+		}
+		if err != nil {
+			run.skip(line, err)
+		}
+		if progress != nil && res.Rows%progressEvery == 0 {
+			progress(res.Progress)
+		}
+	}
+	if err := run.flushTicks(ctx); err != nil {
+		return res, storageError(ctx, errors.Unwrap(err))
+	}
+
+	if im.rollups != nil && !res.From.IsZero() {
+		if err := im.rollups.RollUp(ctx, res.From, res.To.Add(aggregator.Window)); err != nil {
+			return res, storageError(ctx, err)
+		}
+	}
+	slog.Info("Import complete", "kind", opts.Kind, "rows", res.Rows, "saved", res.Saved, "skipped", res.Skipped, "late", res.Late)
+	return res, nil
+}
+
+// storageErr marks a failed write, which aborts the import, as opposed to a
+// bad row, which is skipped.
+type storageErr struct{ err error }
+
+func (e storageErr) Error() string { return e.err.Error() }
+func (e storageErr) Unwrap() error { return e.err }
+
+// storageError classifies a failed write or an abandoned import like the
+// query service does, so callers map it to 499 or 503.
+func storageError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return domain.NewCanceled("request_canceled", "import was canceled", err)
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return domain.NewUnavailable("import_timeout", "import timed out", err)
+	}
+	return domain.NewUnavailable("storage_unavailable", "price storage is unavailable", err)
+}
+
+type seriesKey struct {
+	symbol, exchange string
+}
+
+type windowKey struct {
+	symbol, exchange string
+	start            time.Time
+}
+
+// tickSeries buffers the ticks of one symbol and exchange per minute. A
+// window is written once that series has moved more than a minute past it,
+// so files ordered by time, or by series and then time, aggregate in
+// bounded memory. Every window starting before flushed has been written;
+// later ticks for it are counted as late.
+type tickSeries struct {
+	windows map[time.Time][]float64
+	flushed time.Time
+	latest  time.Time
+}
+
+type importRun struct {
+	im     *Importer
+	res    *Result
+	series map[seriesKey]*tickSeries
+}
+
+func (run *importRun) skip(line int, err error) {
+	run.res.Skipped++
+	if len(run.res.Errors) < maxReportedErrors {
+		run.res.Errors = append(run.res.Errors, fmt.Sprintf("line %d: %v", line, err))
+	}
+}
+
+func (run *importRun) canonical(symbol, exchange string) (string, string, error) {
+	sym, err := run.im.registry.Symbol(symbol)
+	if err != nil {
+		return "", "", err
+	}
+	ex, err := run.im.registry.Exchange(exchange)
+	if err != nil {
+		return "", "", err
+	}
+	return sym, ex, nil
+}
+
+func (run *importRun) addAggregate(ctx context.Context, row record) error {
+	sym, ex, err := run.canonical(row.symbol, row.exchange)
+	if err != nil {
+		return err
+	}
+	if row.interval != "" && row.interval != "1m" {
+		return fmt.Errorf("interval %s: only 1m aggregates can be imported, coarser ones are rolled up from them", row.interval)
+	}
+	if !row.ts.Equal(row.ts.Truncate(aggregator.Window)) {
+		return errors.New("window start is not on a minute boundary")
+	}
+	if row.min > row.avg || row.avg > row.max {
+		return errors.New("expected min <= average <= max")
+	}
+	return run.save(ctx, windowKey{sym, ex, row.ts}, row.avg, row.min, row.max)
+}
+
+func (run *importRun) addTick(ctx context.Context, row record) error {
+	sym, ex, err := run.canonical(row.symbol, row.exchange)
+	if err != nil {
+		return err
+	}
+	key := seriesKey{sym, ex}
+	ser := run.series[key]
+	if ser == nil {
+		ser = &tickSeries{windows: make(map[time.Time][]float64)}
+		run.series[key] = ser
+	}
+	start := row.ts.Truncate(aggregator.Window)
+	if start.Before(ser.flushed) {
+		run.res.Late++
+		return nil
+	}
+	ser.windows[start] = append(ser.windows[start], row.price)
+
+	if row.ts.After(ser.latest) {
+		ser.latest = row.ts
+		return run.flushSeries(ctx, key, ser, ser.latest.Add(-2*aggregator.Window))
+	}
+	return nil
+}
+
+// flushTicks writes every buffered window of every series.
+func (run *importRun) flushTicks(ctx context.Context) error {
+	for key, ser := range run.series {
+		if err := run.flushSeries(ctx, key, ser, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushSeries writes the buffered windows of one series that start before
+// cutoff, or all of them for a zero cutoff.
+func (run *importRun) flushSeries(ctx context.Context, key seriesKey, ser *tickSeries, cutoff time.Time) error {
+	for start, prices := range ser.windows {
+		if !cutoff.IsZero() && !start.Before(cutoff) {
+			continue
+		}
+		min, max, avg := aggregator.CalcStats(prices)
+		if err := run.save(ctx, windowKey{key.symbol, key.exchange, start}, avg, min, max); err != nil {
+			return err
+		}
+		delete(ser.windows, start)
+	}
+	if cutoff.After(ser.flushed) {
+		ser.flushed = cutoff
+	}
+	return nil
+}
+
+func (run *importRun) save(ctx context.Context, key windowKey, avg, min, max float64) error {
+	if err := run.im.save.SaveAggregatedPrice(ctx, key.symbol, key.exchange, key.start, avg, min, max); err != nil {
+		return storageErr{err}
+	}
+	run.res.Saved++
+	if run.res.From.IsZero() || key.start.Before(run.res.From) {
+		run.res.From = key.start
+	}
+	if key.start.After(run.res.To) {
+		run.res.To = key.start
+	}
+	return nil
+}
+
+// record is one parsed input row; ticks use price, aggregates avg/min/max
+// and the interval they were exported at, if the file names it.
+type record struct {
+	symbol, exchange string
+	ts               time.Time
+	price            float64
+	avg, min, max    float64
+	interval         string
+}
+
+type rowReader interface {
+	// next returns the next row and its line number, or io.EOF.
+	next() (record, int, error)
+}
+
+func newRowReader(r io.Reader, opts Options) (rowReader, error) {
+	if opts.Format == FormatNDJSON {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64<<10), 1<<20)
+		return &ndjsonReader{sc: sc, ticks: opts.Kind == KindTicks}, nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, domain.NewInvalidArgument("invalid_body", "CSV input has no header row")
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	want := []string{"pair_name", "exchange", "window_start", "average_price", "min_price", "max_price"}
+	if opts.Kind == KindTicks {
+		want = []string{"symbol", "exchange", "price", "timestamp"}
+	}
+	for _, name := range want {
+		if _, ok := cols[name]; !ok {
+			return nil, domain.NewInvalidArgument("invalid_body", "CSV header is missing column "+name).
+				WithDetail("columns", want)
+		}
+	}
+	return &csvReader{cr: cr, cols: cols, ticks: opts.Kind == KindTicks}, nil
+}
+
+type csvReader struct {
+	cr    *csv.Reader
+	cols  map[string]int
+	ticks bool
+}
+
+func (c *csvReader) next() (record, int, error) {
+	fields, err := c.cr.Read()
+	line, _ := c.cr.FieldPos(0)
+	if errors.Is(err, io.EOF) {
+		return record{}, line, io.EOF
+	}
+	if err != nil {
+		return record{}, line, err
+	}
+	get := func(name string) string {
+		if i, ok := c.cols[name]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	var rec record
+	if c.ticks {
+		rec.symbol, rec.exchange = get("symbol"), get("exchange")
+		if rec.ts, err = parseTickTime(get("timestamp")); err != nil {
+			return rec, line, err
+		}
+		rec.price, err = parsePrice("price", get("price"))
+		return rec, line, err
+	}
+
+	rec.symbol, rec.exchange, rec.interval = get("pair_name"), get("exchange"), get("interval")
+	if rec.ts, err = domain.ParseTimestamp(get("window_start")); err != nil {
+		return rec, line, err
+	}
+	if rec.avg, err = parsePrice("average_price", get("average_price")); err != nil {
+		return rec, line, err
+	}
+	if rec.min, err = parsePrice("min_price", get("min_price")); err != nil {
+		return rec, line, err
+	}
+	rec.max, err = parsePrice("max_price", get("max_price"))
+	return rec, line, err
+}
+
+type ndjsonReader struct {
+	sc    *bufio.Scanner
+	line  int
+	ticks bool
+}
+
+func (n *ndjsonReader) next() (record, int, error) {
+	for n.sc.Scan() {
+		n.line++
+		data := strings.TrimSpace(n.sc.Text())
+		if data == "" {
+			continue
+		}
+
+		var rec record
+		if n.ticks {
+			var t struct {
+				Symbol    string          `json:"symbol"`
+				Exchange  string          `json:"exchange"`
+				Price     float64         `json:"price"`
+				Timestamp json.RawMessage `json:"timestamp"`
+			}
+			if err := json.Unmarshal([]byte(data), &t); err != nil {
+				return rec, n.line, err
+			}
+			ts, err := parseTickTime(strings.Trim(string(t.Timestamp), `"`))
+			if err != nil {
+				return rec, n.line, err
+			}
+			if t.Price <= 0 {
+				return rec, n.line, errors.New("price must be positive")
+			}
+			return record{symbol: t.Symbol, exchange: t.Exchange, ts: ts, price: t.Price}, n.line, nil
+		}
+
+		var a domain.AggregatedResponse
+		if err := json.Unmarshal([]byte(data), &a); err != nil {
+			return rec, n.line, err
+		}
+		ts, err := domain.ParseTimestamp(a.Timestamp)
+		if err != nil {
+			return rec, n.line, err
+		}
+		return record{symbol: a.Pair, exchange: a.Exchange, ts: ts, avg: a.Avg, min: a.Min, max: a.Max, interval: a.Interval}, n.line, nil
+	}
+	if err := n.sc.Err(); err != nil {
+		return record{}, n.line, err
+	}
+	return record{}, n.line, io.EOF
+}
+
+// parseTickTime accepts RFC3339, unix seconds or unix milliseconds, as the
+// exchange feeds send.
+func parseTickTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 1e12 {
+		return time.UnixMilli(n).UTC(), nil
+	}
+	return domain.ParseTimestamp(s)
+}
+
+func parsePrice(name, s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return v, nil
+}
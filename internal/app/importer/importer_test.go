@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+type savedRow struct{ avg, min, max float64 }
+
+type fakeSave struct {
+	rows map[string]savedRow
+	err  error
+}
+
+func (f *fakeSave) SaveAggregatedPrice(ctx context.Context, pair, exchange string, ts time.Time, avg, min, max float64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.rows[pair+"|"+exchange+"|"+ts.UTC().Format(time.RFC3339)] = savedRow{avg, min, max}
+	return nil
+}
+
+func newTestImporter() (*Importer, *fakeSave) {
+	save := &fakeSave{rows: make(map[string]savedRow)}
+	return NewImporter(save, nil, domain.NewRegistry(domain.ExchangeNames, domain.TradingPairs)), save
+}
+
+func TestImportTicks(t *testing.T) {
+	im, save := newTestImporter()
+	input := strings.Join([]string{
+		"symbol,exchange,price,timestamp",
+		"btcusdt,Exchange1,10,1700000000",           // 22:13:20, canonicalised
+		"BTCUSDT,exchange1,20,1700000030000",        // 22:13:50 in milliseconds
+		"BTCUSDT,exchange1,10,2023-11-14T22:13:55Z", // repeated price still counts
+		"XXX,exchange1,1,1700000000",                // unknown symbol
+		"BTCUSDT,exchange1,7,1700000400",            // 22:20:00 flushes 22:13
+		"BTCUSDT,exchange1,99,1700000001",           // late for 22:13
+	}, "\n")
+
+	res, err := im.Import(context.Background(), strings.NewReader(input), Options{Format: FormatCSV, Kind: KindTicks}, nil)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Rows != 6 || res.Saved != 2 || res.Skipped != 1 || res.Late != 1 {
+		t.Errorf("result = %+v, want 6 rows, 2 saved, 1 skipped, 1 late", res.Progress)
+	}
+	want := map[string]savedRow{
+		"BTCUSDT|exchange1|2023-11-14T22:13:00Z": {avg: 40.0 / 3, min: 10, max: 20},
+		"BTCUSDT|exchange1|2023-11-14T22:20:00Z": {avg: 7, min: 7, max: 7},
+	}
+	for key, w := range want {
+		if got, ok := save.rows[key]; !ok || got != w {
+			t.Errorf("row %s = %+v, want %+v", key, got, w)
+		}
+	}
+	if len(res.Errors) != 1 || !strings.HasPrefix(res.Errors[0], "line 5:") {
+		t.Errorf("errors = %q, want one error on line 5", res.Errors)
+	}
+}
+
+func TestImportTicksSortedBySeries(t *testing.T) {
+	im, save := newTestImporter()
+	// A per-pair dump: all of BTCUSDT, then all of ETHUSDT from the same
+	// earlier start. The second series is not late against the first.
+	input := strings.Join([]string{
+		"symbol,exchange,price,timestamp",
+		"BTCUSDT,exchange1,10,2024-01-01T00:00:10Z",
+		"BTCUSDT,exchange1,20,2024-01-01T00:05:10Z",
+		"BTCUSDT,exchange1,30,2024-01-01T00:10:10Z",
+		"ETHUSDT,exchange2,1,2024-01-01T00:00:20Z",
+		"ETHUSDT,exchange2,3,2024-01-01T00:00:40Z",
+		"ETHUSDT,exchange2,2,2024-01-01T00:05:20Z",
+		"ETHUSDT,exchange2,9,2024-01-01T00:00:50Z", // late within ETHUSDT
+	}, "\n")
+
+	res, err := im.Import(context.Background(), strings.NewReader(input), Options{Format: FormatCSV, Kind: KindTicks}, nil)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Saved != 5 || res.Late != 1 || res.Skipped != 0 {
+		t.Errorf("result = %+v, want 5 saved, 1 late", res.Progress)
+	}
+	want := map[string]savedRow{
+		"BTCUSDT|exchange1|2024-01-01T00:00:00Z": {10, 10, 10},
+		"BTCUSDT|exchange1|2024-01-01T00:05:00Z": {20, 20, 20},
+		"BTCUSDT|exchange1|2024-01-01T00:10:00Z": {30, 30, 30},
+		"ETHUSDT|exchange2|2024-01-01T00:00:00Z": {2, 1, 3},
+		"ETHUSDT|exchange2|2024-01-01T00:05:00Z": {2, 2, 2},
+	}
+	for key, w := range want {
+		if got, ok := save.rows[key]; !ok || got != w {
+			t.Errorf("row %s = %+v, want %+v", key, got, w)
+		}
+	}
+}
+
+func TestImportAggregatesIsIdempotent(t *testing.T) {
+	input := `{"pair":"ETHUSDT","exchange":"exchange2","timestamp":"2024-01-01T00:01:00Z","avg":2,"min":1,"max":3}
+{"pair":"ETHUSDT","exchange":"exchange2","timestamp":"2024-01-01T00:01:30Z","avg":2,"min":1,"max":3}
+{"pair":"ETHUSDT","exchange":"exchange2","timestamp":"2024-01-01T00:02:00Z","avg":5,"min":1,"max":3}
+`
+	im, save := newTestImporter()
+	for i := 0; i < 2; i++ {
+		res, err := im.Import(context.Background(), strings.NewReader(input), Options{Format: FormatNDJSON, Kind: KindAggregates}, nil)
+		if err != nil {
+			t.Fatalf("Import: %v", err)
+		}
+		if res.Saved != 1 || res.Skipped != 2 {
+			t.Errorf("run %d: result = %+v, want 1 saved, 2 skipped", i, res.Progress)
+		}
+	}
+	if len(save.rows) != 1 {
+		t.Errorf("stored %d rows, want 1", len(save.rows))
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	im, save := newTestImporter()
+	csv := "pair_name,exchange,window_start,average_price,min_price,max_price\nBTCUSDT,exchange1,2024-01-01T00:00:00Z,2,1,3\n"
+
+	if _, err := im.Import(context.Background(), strings.NewReader(csv), Options{Format: "xml", Kind: KindAggregates}, nil); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("bad format: err = %v, want invalid argument", err)
+	}
+	if _, err := im.Import(context.Background(), strings.NewReader("a,b\n"), Options{Format: FormatCSV, Kind: KindAggregates}, nil); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("bad header: err = %v, want invalid argument", err)
+	}
+
+	save.err = errors.New("connection refused")
+	if _, err := im.Import(context.Background(), strings.NewReader(csv), Options{Format: FormatCSV, Kind: KindAggregates}, nil); !errors.Is(err, domain.ErrUnavailable) {
+		t.Errorf("storage failure: err = %v, want unavailable", err)
+	}
+
+	save.err = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := im.Import(ctx, strings.NewReader(csv), Options{Format: FormatCSV, Kind: KindAggregates}, nil); !errors.Is(err, domain.ErrCanceled) {
+		t.Errorf("canceled: err = %v, want canceled", err)
+	}
+}
+
+func TestImportAggregatesOnlyAtMinuteInterval(t *testing.T) {
+	im, save := newTestImporter()
+	// An hourly export is stamped on minute boundaries too, so only its
+	// interval column tells it from minute rows.
+	input := strings.Join([]string{
+		"pair_name,exchange,window_start,average_price,min_price,max_price,interval",
+		"BTCUSDT,exchange1,2024-01-01T00:00:00Z,2,1,3,1m",
+		"BTCUSDT,exchange1,2024-01-01T01:00:00Z,2,1,3,1h",
+		"BTCUSDT,exchange1,2024-01-01T00:01:00Z,2,1,3,",
+	}, "\n")
+	res, err := im.Import(context.Background(), strings.NewReader(input), Options{Format: FormatCSV, Kind: KindAggregates}, nil)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Saved != 2 || res.Skipped != 1 || len(res.Errors) != 1 || !strings.Contains(res.Errors[0], "line 3: interval 1h") {
+		t.Errorf("result = %+v, errors %q; want 2 saved and the 1h row skipped", res.Progress, res.Errors)
+	}
+	if _, ok := save.rows["BTCUSDT|exchange1|2024-01-01T01:00:00Z"]; ok {
+		t.Error("the hourly row was stored as a minute row")
+	}
+
+	ndjson := `{"pair":"ETHUSDT","exchange":"exchange2","timestamp":"2024-01-01T00:00:00Z","avg":2,"min":1,"max":3,"interval":"5m"}` + "\n"
+	res, err = im.Import(context.Background(), strings.NewReader(ndjson), Options{Format: FormatNDJSON, Kind: KindAggregates}, nil)
+	if err != nil || res.Saved != 0 || res.Skipped != 1 {
+		t.Errorf("5m NDJSON row: result = %+v, %v; want it skipped", res.Progress, err)
+	}
+}
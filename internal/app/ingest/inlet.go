@@ -0,0 +1,210 @@
+package ingest
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
+
+// Policy decides what a source does with a tick that arrives while its
+// inlet's buffer is full. It is separate from fanout.Policy because a source
+// buffer can also be coalesced: a source's ticks are prices that supersede
+// each other per symbol, while fan-out queues are consumed as they are.
+type Policy string
+
+const (
+	// PolicyBlock keeps every tick: Send waits for room, so the source stops
+	// reading its feed (and the exchange's TCP window fills) until the
+	// pipeline takes a buffered tick.
+	PolicyBlock Policy = "block"
+	// PolicyDropNewest keeps the buffered ticks and loses the one arriving,
+	// so the buffer holds the oldest unsent prices.
+	PolicyDropNewest Policy = "drop-newest"
+	// PolicyDropOldest evicts the tick that has waited longest and buffers
+	// the arriving one, so the buffer holds the most recent prices.
+	PolicyDropOldest Policy = "drop-oldest"
+	// PolicyCoalesce buffers at most one tick per symbol: an arriving tick
+	// replaces the pending one of its symbol, which keeps its place in line.
+	// No buffer size applies, since the symbols bound it.
+	PolicyCoalesce Policy = "coalesce-latest-per-symbol"
+)
+
+const DefaultBufferSize = 1000
+
+var policies = []Policy{PolicyBlock, PolicyDropNewest, PolicyDropOldest, PolicyCoalesce}
+
+// ParsePolicy validates a configured policy name. An empty name means
+// PolicyBlock.
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return PolicyBlock, nil
+	}
+	for _, p := range policies {
+		if Policy(s) == p {
+			return p, nil
+		}
+	}
+	return "", domain.NewInvalidArgument("invalid_backpressure_policy", "invalid backpressure policy: "+s).
+		WithDetail("valid_policies", policies)
+}
+
+// Inlet is the buffered entry point of one source into the shared updates
+// channel. Sources call Send; run moves buffered ticks downstream.
+type Inlet struct {
+	exchange string
+	policy   Policy
+	out      chan<- domain.PriceUpdate
+	counters *counters
+
+	queue chan domain.PriceUpdate
+
+	// Pending ticks for PolicyCoalesce, in arrival order of their symbol.
+	mu      sync.Mutex
+	pending map[string]domain.PriceUpdate
+	order   []string
+	notify  chan struct{}
+
+	closing chan struct{}
+}
+
+func newInlet(exchange string, policy Policy, size int, out chan<- domain.PriceUpdate, c *counters) *Inlet {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Inlet{
+		exchange: exchange,
+		policy:   policy,
+		out:      out,
+		counters: c,
+		queue:    make(chan domain.PriceUpdate, size),
+		pending:  make(map[string]domain.PriceUpdate),
+		notify:   make(chan struct{}, 1),
+		closing:  make(chan struct{}),
+	}
+}
+
+// Send hands a tick to the pipeline according to the inlet's policy. It
+// reports false once ctx is done, telling the source to stop.
+func (in *Inlet) Send(ctx context.Context, update domain.PriceUpdate) bool {
+	metrics.TicksReceived.Inc(update.Exchange, update.Symbol)
+	switch in.policy {
+	case PolicyBlock:
+		select {
+		case in.queue <- update:
+		case <-ctx.Done():
+			return false
+		}
+	case PolicyDropNewest:
+		select {
+		case in.queue <- update:
+		default:
+			in.counters.drop(update)
+			slog.Debug("Source buffer full, newest tick dropped", "exchange", update.Exchange, "symbol", update.Symbol)
+		}
+	case PolicyDropOldest:
+		select {
+		case in.queue <- update:
+		default:
+			// The pump may take the oldest tick first; either way one tick
+			// is lost and counted.
+			select {
+			case old := <-in.queue:
+				in.counters.drop(old)
+			default:
+			}
+			select {
+			case in.queue <- update:
+			default:
+				in.counters.drop(update)
+			}
+			slog.Debug("Source buffer full, oldest tick dropped", "exchange", update.Exchange, "symbol", update.Symbol)
+		}
+	case PolicyCoalesce:
+		in.mu.Lock()
+		if _, ok := in.pending[update.Symbol]; ok {
+			in.counters.coalesce(update)
+		} else {
+			in.order = append(in.order, update.Symbol)
+		}
+		in.pending[update.Symbol] = update
+		in.mu.Unlock()
+
+		select {
+		case in.notify <- struct{}{}:
+		default:
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// run forwards buffered ticks to the shared channel until ctx is done, or
+// until the inlet is closed, in which case everything still buffered is
+// forwarded first.
+func (in *Inlet) run(ctx context.Context) {
+	slog.Info("Source inlet started", "exchange", in.exchange, "policy", in.policy, "buffer", cap(in.queue))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-in.closing:
+			in.drain(ctx)
+			return
+		case update := <-in.queue:
+			if !in.forward(ctx, update) {
+				return
+			}
+		case <-in.notify:
+			if !in.flushPending(ctx) {
+				return
+			}
+		}
+	}
+}
+
+func (in *Inlet) drain(ctx context.Context) {
+	for {
+		select {
+		case update := <-in.queue:
+			if !in.forward(ctx, update) {
+				return
+			}
+		default:
+			in.flushPending(ctx)
+			slog.Info("Source inlet drained", "exchange", in.exchange)
+			return
+		}
+	}
+}
+
+func (in *Inlet) flushPending(ctx context.Context) bool {
+	in.mu.Lock()
+	batch := make([]domain.PriceUpdate, 0, len(in.order))
+	for _, sym := range in.order {
+		batch = append(batch, in.pending[sym])
+		delete(in.pending, sym)
+	}
+	in.order = in.order[:0]
+	in.mu.Unlock()
+
+	for _, update := range batch {
+		if !in.forward(ctx, update) {
+			return false
+		}
+	}
+	return true
+}
+
+func (in *Inlet) forward(ctx context.Context, update domain.PriceUpdate) bool {
+	select {
+	case in.out <- update:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package ingest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"marketflow/internal/domain"
+)
+
+func tick(symbol string, price float64) domain.PriceUpdate {
+	return domain.PriceUpdate{Exchange: "exchange1", Symbol: symbol, Price: price}
+}
+
+// buffered returns the prices waiting in the inlet, in the order the pump
+// would forward them.
+func buffered(in *Inlet) []float64 {
+	prices := []float64{}
+	for len(in.queue) > 0 {
+		prices = append(prices, (<-in.queue).Price)
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, sym := range in.order {
+		prices = append(prices, in.pending[sym].Price)
+	}
+	return prices
+}
+
+func TestInletFullBuffer(t *testing.T) {
+	tests := []struct {
+		policy    Policy
+		sent      []domain.PriceUpdate
+		want      []float64
+		dropped   uint64
+		coalesced uint64
+	}{
+		// The pump is not running, so a buffer of two is full after two ticks.
+		{PolicyDropOldest, []domain.PriceUpdate{tick("BTCUSDT", 1), tick("BTCUSDT", 2), tick("BTCUSDT", 3), tick("BTCUSDT", 4)}, []float64{3, 4}, 2, 0},
+		{PolicyDropNewest, []domain.PriceUpdate{tick("BTCUSDT", 1), tick("BTCUSDT", 2), tick("BTCUSDT", 3), tick("BTCUSDT", 4)}, []float64{1, 2}, 2, 0},
+		{PolicyCoalesce, []domain.PriceUpdate{tick("BTCUSDT", 1), tick("ETHUSDT", 2), tick("BTCUSDT", 3), tick("BTCUSDT", 4)}, []float64{4, 2}, 0, 2},
+	}
+	for _, tt := range tests {
+		c := newCounters()
+		in := newInlet("exchange1", tt.policy, 2, make(chan domain.PriceUpdate), c)
+		for _, u := range tt.sent {
+			if !in.Send(context.Background(), u) {
+				t.Fatalf("%s: Send reported the source should stop", tt.policy)
+			}
+		}
+		if got := buffered(in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: buffered %v, want %v", tt.policy, got, tt.want)
+		}
+		var dropped, coalesced uint64
+		for _, s := range c.snapshot() {
+			dropped += s.Dropped
+			coalesced += s.Coalesced
+		}
+		if dropped != tt.dropped || coalesced != tt.coalesced {
+			t.Errorf("%s: %d dropped, %d coalesced; want %d and %d", tt.policy, dropped, coalesced, tt.dropped, tt.coalesced)
+		}
+	}
+}
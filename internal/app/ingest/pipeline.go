@@ -0,0 +1,136 @@
+package ingest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
+
+// SourceConfig is the backpressure setup of one source.
+type SourceConfig struct {
+	Exchange string
+	Policy   Policy
+	Buffer   int
+}
+
+// Pipeline owns one Inlet per source, all feeding the same updates channel,
+// and the counters of ticks they lost.
+type Pipeline struct {
+	inlets   map[string]*Inlet
+	counters *counters
+	out      chan<- domain.PriceUpdate
+	running  sync.WaitGroup
+}
+
+func NewPipeline(out chan<- domain.PriceUpdate, sources []SourceConfig) *Pipeline {
+	p := &Pipeline{inlets: make(map[string]*Inlet, len(sources)), counters: newCounters(), out: out}
+	for _, src := range sources {
+		p.inlets[src.Exchange] = newInlet(src.Exchange, src.Policy, src.Buffer, out, p.counters)
+	}
+	return p
+}
+
+// Inlet returns the inlet of exchange, or nil if it was not configured.
+func (p *Pipeline) Inlet(exchange string) *Inlet {
+	return p.inlets[exchange]
+}
+
+// Run starts every inlet; they stop when ctx is done or on Close.
+func (p *Pipeline) Run(ctx context.Context) {
+	for _, in := range p.inlets {
+		p.running.Add(1)
+		go func(in *Inlet) {
+			defer p.running.Done()
+			in.run(ctx)
+		}(in)
+	}
+}
+
+// Close forwards every buffered tick and then closes the updates channel,
+// letting downstream consumers drain and exit. Sources must already be
+// stopped. If ctx expires first, the channel is left open and ctx's error is
+// returned; cancelling the Run context then stops the inlets.
+func (p *Pipeline) Close(ctx context.Context) error {
+	for _, in := range p.inlets {
+		close(in.closing)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		close(p.out)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Counters returns the dropped and coalesced tick counts of every series
+// that lost ticks.
+func (p *Pipeline) Counters() []SeriesCounters {
+	return p.counters.snapshot()
+}
+
+type SeriesCounters struct {
+	Exchange  string `json:"exchange"`
+	Symbol    string `json:"symbol"`
+	Dropped   uint64 `json:"dropped"`
+	Coalesced uint64 `json:"coalesced"`
+}
+
+type counters struct {
+	mu     sync.Mutex
+	series map[domain.SeriesKey]*SeriesCounters
+}
+
+func newCounters() *counters {
+	return &counters{series: make(map[domain.SeriesKey]*SeriesCounters)}
+}
+
+func (c *counters) drop(update domain.PriceUpdate) {
+	c.mu.Lock()
+	c.get(update).Dropped++
+	c.mu.Unlock()
+	metrics.TicksDropped.Inc(update.Exchange, update.Symbol)
+}
+
+func (c *counters) coalesce(update domain.PriceUpdate) {
+	c.mu.Lock()
+	c.get(update).Coalesced++
+	c.mu.Unlock()
+	metrics.TicksCoalesced.Inc(update.Exchange, update.Symbol)
+}
+
+func (c *counters) get(update domain.PriceUpdate) *SeriesCounters {
+	key := domain.SeriesKey{Exchange: update.Exchange, Symbol: update.Symbol}
+	s, ok := c.series[key]
+	if !ok {
+		s = &SeriesCounters{Exchange: update.Exchange, Symbol: update.Symbol}
+		c.series[key] = s
+	}
+	return s
+}
+
+func (c *counters) snapshot() []SeriesCounters {
+	c.mu.Lock()
+	out := make([]SeriesCounters, 0, len(c.series))
+	for _, s := range c.series {
+		out = append(out, *s)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Exchange != out[j].Exchange {
+			return out[i].Exchange < out[j].Exchange
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
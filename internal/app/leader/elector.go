@@ -0,0 +1,165 @@
+// Package leader elects one replica to run work that must not be duplicated,
+// such as aggregation, using a lease that the holder keeps renewing.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"marketflow/internal/app"
+	"marketflow/internal/metrics"
+)
+
+const (
+	DefaultKey      = "marketflow:aggregator:leader"
+	DefaultLeaseTTL = 15 * time.Second
+)
+
+type Role string
+
+const (
+	RoleLeader   Role = "leader"
+	RoleFollower Role = "follower"
+)
+
+// Status is the elector's view of its own role.
+type Status struct {
+	Role     Role      `json:"role"`
+	Instance string    `json:"instance"`
+	Since    time.Time `json:"since"`
+}
+
+type Elector struct {
+	leases app.LeaseRepo
+	key    string
+	id     string
+	ttl    time.Duration
+
+	mu    sync.RWMutex
+	role  Role
+	since time.Time
+}
+
+// NewElector builds an elector competing for key under this process's
+// instance ID. A zero ttl means DefaultLeaseTTL.
+func NewElector(leases app.LeaseRepo, key string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	return &Elector{
+		leases: leases,
+		key:    key,
+		id:     InstanceID(),
+		ttl:    ttl,
+		role:   RoleFollower,
+		since:  time.Now().UTC(),
+	}
+}
+
+// InstanceID identifies this process among the replicas: its host name, which
+// is the container ID under Docker, and its PID.
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Run competes for the lease until ctx is done, renewing it every third of
+// its TTL. While this instance holds the lease, work runs with a context that
+// is cancelled as soon as the lease cannot be renewed. Run returns after work
+// has stopped and the lease has been released.
+func (e *Elector) Run(ctx context.Context, work func(ctx context.Context)) {
+	slog.Info("Leader election started", "key", e.key, "instance", e.id, "ttl", e.ttl)
+	metrics.Leader.Set(0)
+
+	var (
+		stopWork context.CancelFunc
+		workDone chan struct{}
+	)
+	stepDown := func(reason string) {
+		if stopWork == nil {
+			return
+		}
+		stopWork()
+		<-workDone
+		stopWork, workDone = nil, nil
+		e.setRole(RoleFollower)
+		slog.Warn("Lost leadership", "instance", e.id, "reason", reason)
+	}
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		held, err := e.leases.AcquireLease(ctx, e.key, e.id, e.ttl)
+		switch {
+		case ctx.Err() != nil:
+		case err != nil:
+			// Without a renewal the lease may expire and pass to another
+			// replica, so stop working rather than risk two leaders.
+			stepDown("lease renewal failed")
+		case !held:
+			stepDown("lease held by another instance")
+		case stopWork == nil:
+			var workCtx context.Context
+			workCtx, stopWork = context.WithCancel(ctx)
+			workDone = make(chan struct{})
+			e.setRole(RoleLeader)
+			slog.Info("Elected leader", "instance", e.id)
+			go func(done chan struct{}) {
+				defer close(done)
+				work(workCtx)
+			}(workDone)
+		}
+
+		select {
+		case <-ctx.Done():
+			wasLeader := stopWork != nil
+			stepDown("shutting down")
+			if wasLeader {
+				e.release()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+	defer cancel()
+	if err := e.leases.ReleaseLease(ctx, e.key, e.id); err != nil {
+		slog.Warn("Failed to release leader lease, it will expire", "ttl", e.ttl, "err", err)
+		return
+	}
+	slog.Info("Released leader lease", "instance", e.id)
+}
+
+func (e *Elector) setRole(role Role) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.role, e.since = role, time.Now().UTC()
+	if role == RoleLeader {
+		metrics.Leader.Set(1)
+	} else {
+		metrics.Leader.Set(0)
+	}
+}
+
+// IsLeader reports whether this instance currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.role == RoleLeader
+}
+
+func (e *Elector) Status() Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return Status{Role: e.role, Instance: e.id, Since: e.since}
+}
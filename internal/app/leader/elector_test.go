@@ -0,0 +1,122 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/app"
+)
+
+const testTTL = 60 * time.Millisecond
+
+func newTestElector(leases app.LeaseRepo, id string) *Elector {
+	e := NewElector(leases, DefaultKey, testTTL)
+	e.id = id
+	return e
+}
+
+// runElector runs e until the returned stop function is called, counting
+// how many times work is running.
+func runElector(e *Elector, working *atomic.Int32) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.Run(ctx, func(ctx context.Context) {
+			working.Add(1)
+			defer working.Add(-1)
+			<-ctx.Done()
+		})
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestElectorHandoff(t *testing.T) {
+	leases := memory.NewSortedSets()
+	a, b := newTestElector(leases, "a"), newTestElector(leases, "b")
+	var workA, workB atomic.Int32
+
+	stopA := runElector(a, &workA)
+	waitFor(t, "a to lead", a.IsLeader)
+	stopB := runElector(b, &workB)
+	defer stopB()
+
+	// b keeps competing for a few renewals without taking over.
+	time.Sleep(3 * testTTL)
+	if !a.IsLeader() || b.IsLeader() || workA.Load() != 1 || workB.Load() != 0 {
+		t.Fatalf("a leader %v working %d, b leader %v working %d; want only a", a.IsLeader(), workA.Load(), b.IsLeader(), workB.Load())
+	}
+
+	// Work stops before Run returns, and b takes over.
+	stopA()
+	if a.IsLeader() || workA.Load() != 0 {
+		t.Fatal("a still leads after Run returned")
+	}
+	waitFor(t, "b to lead", b.IsLeader)
+	waitFor(t, "b to start working", func() bool { return workB.Load() == 1 })
+	if s := b.Status(); s.Role != RoleLeader || s.Instance != "b" {
+		t.Errorf("b status = %+v", s)
+	}
+}
+
+func TestElectorReleasesOnShutdown(t *testing.T) {
+	leases := memory.NewSortedSets()
+	e := newTestElector(leases, "a")
+	var work atomic.Int32
+	stop := runElector(e, &work)
+	waitFor(t, "election", e.IsLeader)
+	stop()
+
+	// Released rather than left to expire: another owner gets it at once.
+	if ok, err := leases.AcquireLease(context.Background(), DefaultKey, "b", time.Hour); err != nil || !ok {
+		t.Errorf("AcquireLease after shutdown = %v, %v; want the lease free", ok, err)
+	}
+}
+
+// flakyLeases fails every renewal once fail is set.
+type flakyLeases struct {
+	*memory.SortedSets
+	fail atomic.Bool
+}
+
+func (f *flakyLeases) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	if f.fail.Load() {
+		return false, errors.New("connection refused")
+	}
+	return f.SortedSets.AcquireLease(ctx, key, owner, ttl)
+}
+
+func TestElectorStepsDownWhenRenewalFails(t *testing.T) {
+	leases := &flakyLeases{SortedSets: memory.NewSortedSets()}
+	e := newTestElector(leases, "a")
+	var work atomic.Int32
+	stop := runElector(e, &work)
+	defer stop()
+
+	waitFor(t, "election", e.IsLeader)
+	leases.fail.Store(true)
+	waitFor(t, "step down", func() bool { return !e.IsLeader() && work.Load() == 0 })
+
+	leases.fail.Store(false)
+	waitFor(t, "re-election", func() bool { return e.IsLeader() && work.Load() == 1 })
+}
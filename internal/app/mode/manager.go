@@ -2,13 +2,15 @@ package mode
 
 import (
 	"context"
-	"errors"
 	"log/slog"
+	"strings"
 	"sync"
 
 	"marketflow/internal/adapters/generator"
 	"marketflow/internal/adapters/websocket"
+	"marketflow/internal/app/ingest"
 	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
 )
 
 type Mode int
@@ -18,17 +20,44 @@ const (
 	ModeTest
 )
 
+func (m Mode) String() string {
+	switch m {
+	case ModeLive:
+		return "live"
+	case ModeTest:
+		return "test"
+	}
+	return "unknown"
+}
+
+func Parse(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "live":
+		return ModeLive, nil
+	case "test":
+		return ModeTest, nil
+	}
+	return ModeLive, domain.NewInvalidArgument("invalid_mode", "invalid mode: "+s)
+}
+
 type Manager struct {
-	current Mode
-	cancel  context.CancelFunc
-	mu      sync.Mutex
-	out     chan<- domain.PriceUpdate
+	current  Mode
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	pipeline *ingest.Pipeline
+	registry *domain.Registry
+	sources  []websocket.Source
+	running  sync.WaitGroup
 }
 
-func NewModeManager(out chan<- domain.PriceUpdate) *Manager {
+// NewModeManager builds a manager whose sources, live or generated, send
+// through the pipeline inlet of their exchange.
+func NewModeManager(pipeline *ingest.Pipeline, registry *domain.Registry, sources []websocket.Source) *Manager {
 	return &Manager{
-		current: ModeLive,
-		out:     out,
+		current:  ModeLive,
+		pipeline: pipeline,
+		registry: registry,
+		sources:  sources,
 	}
 }
 
@@ -40,7 +69,7 @@ func (m *Manager) SetMode(ctx context.Context, mode Mode) error {
 
 	if mode != ModeLive && mode != ModeTest {
 		slog.Error("Invalid mode value", "mode", mode)
-		return errors.New("invalid mode")
+		return domain.NewInvalidArgument("invalid_mode", "invalid mode")
 	}
 
 	if m.cancel != nil {
@@ -51,14 +80,31 @@ func (m *Manager) SetMode(ctx context.Context, mode Mode) error {
 	newCtx, cancel := context.WithCancel(context.Background())
 	m.cancel = cancel
 	m.current = mode
+	metrics.Mode.Set(0, ModeLive.String())
+	metrics.Mode.Set(0, ModeTest.String())
+	metrics.Mode.Set(1, mode.String())
 
 	switch mode {
 	case ModeLive:
 		slog.Info("Switched to Live Mode")
-		go websocket.StartReaders(m.out)
+		sources := make([]websocket.Source, len(m.sources))
+		for i, src := range m.sources {
+			src.Sink = m.pipeline.Inlet(src.Name)
+			sources[i] = src
+		}
+		m.running.Add(1)
+		go func() {
+			defer m.running.Done()
+			websocket.StartReaders(newCtx, sources)
+		}()
 	case ModeTest:
 		slog.Info("Switched to Test Mode")
-		go generator.StartTestGenerators(newCtx, m.out)
+		sinkFor := func(exchange string) generator.Sink { return m.pipeline.Inlet(exchange) }
+		m.running.Add(1)
+		go func() {
+			defer m.running.Done()
+			generator.StartTestGenerators(newCtx, m.registry.Exchanges(), m.registry.Symbols(), sinkFor)
+		}()
 	}
 
 	return nil
@@ -67,6 +113,30 @@ func (m *Manager) SetMode(ctx context.Context, mode Mode) error {
 func (m *Manager) GetMode() Mode {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	slog.Info("GetMode called", "current_mode", m.current)
+	slog.Debug("GetMode called", "current_mode", m.current)
 	return m.current
 }
+
+// Stop cancels the current mode and waits until its sources have stopped or
+// ctx is done.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		slog.Info("All sources stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
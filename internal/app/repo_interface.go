@@ -8,29 +8,49 @@ import (
 )
 
 type APIRepo interface {
-	GetPriceForSymbol(symbol string) (*domain.AggregatedResponse, error)
-	GetPriceForExchange(exchange, symbol string) (*domain.AggregatedResponse, error)
-	GetHighestBySymbol(symbol string) (*domain.AggregatedResponse, error)
-	GetHighestByExchange(exchange, symbol string) (*domain.AggregatedResponse, error)
-	QueryHighestPriceSince(symbol string, since time.Time) (*domain.AggregatedResponse, error)
-	QueryHighestSinceByExchange(exchange, symbol string, since time.Time) (*domain.AggregatedResponse, error)
-	GetLowestBySymbol(symbol string) (*domain.AggregatedResponse, error)
-	GetLowestByExchange(exchange, symbol string) (*domain.AggregatedResponse, error)
-	QueryLowestPriceSince(symbol string, since time.Time) (*domain.AggregatedResponse, error)
-	QueryLowestSinceByExchange(exchange, symbol string, since time.Time) (*domain.AggregatedResponse, error)
-	GetAvgBySymbol(symbol string) (*domain.AggregatedResponse, error)
-	GetAvgByExchange(exchange, symbol string) (*domain.AggregatedResponse, error)
-	QueryAvgSinceByExchange(exchange, symbol string, since time.Time) (*domain.AggregatedResponse, error)
-	Ping() error
+	GetPriceForSymbol(ctx context.Context, symbol string) (*domain.AggregatedResponse, error)
+	GetPriceForExchange(ctx context.Context, exchange, symbol string) (*domain.AggregatedResponse, error)
+	QueryLatestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryLatestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryHighestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryHighestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryLowestInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryLowestInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryAvgInRange(ctx context.Context, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	QueryAvgInRangeByExchange(ctx context.Context, exchange, symbol string, rng domain.TimeRange) (*domain.AggregatedResponse, error)
+	GetHistory(ctx context.Context, q domain.HistoryQuery) ([]domain.HistoryRecord, error)
+	GetCoverage(ctx context.Context) ([]domain.Coverage, error)
+	QueryBatch(ctx context.Context, stat string, rng domain.TimeRange, keys []domain.SeriesKey) (map[domain.SeriesKey]*domain.AggregatedResponse, error)
+	StreamAggregates(ctx context.Context, q domain.ExportQuery, fn func(domain.AggregatedResponse) error) error
+	Ping(ctx context.Context) error
 }
 
 type RedisRepo interface {
 	ZAdd(ctx context.Context, key string, score int64, value string) error
 	ZRangeByScore(ctx context.Context, key string, min, max int64) ([]string, error)
 	ZRemRangeByScore(ctx context.Context, key string, min, max int64) error
+	ZMaxScore(ctx context.Context, key string) (int64, bool, error)
 	Ping(ctx context.Context) error
 }
 
+// LeaseRepo stores a single-holder lease with an expiry, used to elect the
+// replica that aggregates.
+type LeaseRepo interface {
+	AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, key, owner string) error
+}
+
 type SavePGRepo interface {
 	SaveAggregatedPrice(ctx context.Context, pair, exchange string, ts time.Time, avg, min, max float64) error
 }
+
+// RollupRepo materialises coarser aggregates from finer ones and deletes rows
+// past their retention.
+type RollupRepo interface {
+	Rollup(ctx context.Context, source, target time.Duration, from, to time.Time) (int64, error)
+	PruneAggregates(ctx context.Context, interval time.Duration, before time.Time) (int64, error)
+}
+
+type AggregatePublisher interface {
+	PublishAggregate(agg domain.AggregatedResponse)
+}
@@ -0,0 +1,108 @@
+// Package rollup materialises coarser aggregates from the minute rows and
+// enforces per-resolution retention.
+package rollup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"marketflow/internal/app"
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
+
+const (
+	// cycleOffset runs each cycle after the aggregator has written the
+	// previous minute.
+	cycleOffset = 10 * time.Second
+
+	// backfill is how far back the first cycle rolls up, covering whatever
+	// the previous leader left unfinished.
+	backfill = 24 * time.Hour
+)
+
+type Job struct {
+	repo        app.RollupRepo
+	resolutions []domain.Resolution
+}
+
+// NewJob builds a rollup job over resolutions ordered from finest to
+// coarsest; each is rolled up from the one before it.
+func NewJob(repo app.RollupRepo, resolutions []domain.Resolution) *Job {
+	return &Job{repo: repo, resolutions: resolutions}
+}
+
+// RollUp rebuilds every bucket of each coarser resolution that overlaps
+// [from, to), finest first so each level reads fresh rows.
+func (j *Job) RollUp(ctx context.Context, from, to time.Time) error {
+	for i := 1; i < len(j.resolutions); i++ {
+		source, target := j.resolutions[i-1], j.resolutions[i]
+		bucketFrom := from.UTC().Truncate(target.Interval)
+		bucketTo := to.UTC().Truncate(target.Interval)
+		if bucketTo.Before(to) {
+			bucketTo = bucketTo.Add(target.Interval)
+		}
+
+		n, err := j.repo.Rollup(ctx, source.Interval, target.Interval, bucketFrom, bucketTo)
+		if err != nil {
+			return fmt.Errorf("roll up %s: %w", target.Name, err)
+		}
+		metrics.RollupRowsWritten.Add(float64(n), target.Name)
+		slog.Debug("Rolled up aggregates", "resolution", target.Name, "from", bucketFrom, "to", bucketTo, "rows", n)
+	}
+	return nil
+}
+
+// Run rolls up and prunes once a minute until ctx is done. Every cycle
+// recomputes the buckets holding the previous and the current minute, so
+// rollups trail the minute rows by at most one cycle.
+func (j *Job) Run(ctx context.Context) {
+	slog.Info("Rollup job started", "resolutions", len(j.resolutions))
+	j.cycle(ctx, time.Now(), backfill)
+
+	for {
+		next := time.Now().UTC().Truncate(time.Minute).Add(time.Minute + cycleOffset)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Rollup job stopped")
+			return
+		case <-timer.C:
+			j.cycle(ctx, time.Now(), 0)
+		}
+	}
+}
+
+// cycle rolls up every bucket touched since lookback ago, plus the minute
+// before it, whose row the aggregator may have written since the last cycle.
+// It then deletes rows past their retention.
+func (j *Job) cycle(ctx context.Context, now time.Time, lookback time.Duration) {
+	start := time.Now()
+	now = now.UTC()
+
+	if err := j.RollUp(ctx, now.Add(-lookback-time.Minute), now); err != nil {
+		// Coarser resolutions build on finer ones, so the whole chain is
+		// retried next cycle.
+		slog.Error("Rollup failed", "err", err)
+		return
+	}
+
+	for _, r := range j.resolutions {
+		if r.Retention == 0 {
+			continue
+		}
+		n, err := j.repo.PruneAggregates(ctx, r.Interval, now.Add(-r.Retention))
+		if err != nil {
+			slog.Error("Pruning aggregates failed", "resolution", r.Name, "err", err)
+			continue
+		}
+		if n > 0 {
+			metrics.RollupRowsPruned.Add(float64(n), r.Name)
+			slog.Info("Pruned aggregates past retention", "resolution", r.Name, "rows", n, "retention", r.Retention)
+		}
+	}
+	slog.Debug("Rollup cycle complete", "duration", time.Since(start))
+}
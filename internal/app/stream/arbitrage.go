@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+const (
+	DefaultArbitrageThresholdBps = 50
+	arbitrageMaxAge              = 10 * time.Second
+	arbitrageCooldown            = 10 * time.Second
+)
+
+type observedTick struct {
+	price float64
+	at    time.Time
+}
+
+// arbitrageDetector tracks the latest price of every symbol on every exchange
+// and reports when the spread between the cheapest and the dearest exchange
+// exceeds the threshold. Prices older than arbitrageMaxAge are ignored and a
+// symbol alerts at most once per arbitrageCooldown.
+type arbitrageDetector struct {
+	thresholdBps float64
+	last         map[string]map[string]observedTick
+	lastAlert    map[string]time.Time
+}
+
+func newArbitrageDetector(thresholdBps float64) *arbitrageDetector {
+	return &arbitrageDetector{
+		thresholdBps: thresholdBps,
+		last:         make(map[string]map[string]observedTick),
+		lastAlert:    make(map[string]time.Time),
+	}
+}
+
+func (d *arbitrageDetector) observe(update domain.PriceUpdate, now time.Time) *domain.ArbitrageAlert {
+	byExchange, ok := d.last[update.Symbol]
+	if !ok {
+		byExchange = make(map[string]observedTick)
+		d.last[update.Symbol] = byExchange
+	}
+	byExchange[update.Exchange] = observedTick{price: update.Price, at: now}
+
+	if now.Sub(d.lastAlert[update.Symbol]) < arbitrageCooldown {
+		return nil
+	}
+
+	var buyEx, sellEx string
+	var buy, sell float64
+	for ex, t := range byExchange {
+		if now.Sub(t.at) > arbitrageMaxAge || t.price <= 0 {
+			continue
+		}
+		if buyEx == "" || t.price < buy {
+			buyEx, buy = ex, t.price
+		}
+		if sellEx == "" || t.price > sell {
+			sellEx, sell = ex, t.price
+		}
+	}
+	if buyEx == "" || buyEx == sellEx {
+		return nil
+	}
+
+	spread := (sell - buy) / buy * 10000
+	if spread < d.thresholdBps {
+		return nil
+	}
+
+	d.lastAlert[update.Symbol] = now
+	return &domain.ArbitrageAlert{
+		Symbol:       update.Symbol,
+		BuyExchange:  buyEx,
+		BuyPrice:     buy,
+		SellExchange: sellEx,
+		SellPrice:    sell,
+		SpreadBps:    spread,
+		Timestamp:    now.UTC().Format(time.RFC3339),
+	}
+}
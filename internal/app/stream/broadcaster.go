@@ -0,0 +1,237 @@
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+const (
+	EventTick      = "tick"
+	EventAggregate = "aggregate"
+	EventArbitrage = "arbitrage"
+)
+
+const DefaultBuffer = 256
+
+type Event struct {
+	Type      string
+	Tick      *domain.PriceUpdate
+	Aggregate *domain.AggregatedResponse
+	Arbitrage *domain.ArbitrageAlert
+}
+
+// Series returns the symbol and exchange the event belongs to. Arbitrage
+// alerts span exchanges and report an empty exchange.
+func (e Event) Series() (symbol, exchange string) {
+	switch e.Type {
+	case EventTick:
+		return e.Tick.Symbol, e.Tick.Exchange
+	case EventAggregate:
+		return e.Aggregate.Pair, e.Aggregate.Exchange
+	case EventArbitrage:
+		return e.Arbitrage.Symbol, ""
+	}
+	return "", ""
+}
+
+// Filter selects the events a subscriber receives. Empty symbol or exchange
+// sets match everything; the exchange set does not apply to arbitrage alerts.
+type Filter struct {
+	Symbols    map[string]bool
+	Exchanges  map[string]bool
+	Ticks      bool
+	Aggregates bool
+	Arbitrage  bool
+}
+
+func (f Filter) Match(e Event) bool {
+	switch e.Type {
+	case EventTick:
+		if !f.Ticks {
+			return false
+		}
+	case EventAggregate:
+		if !f.Aggregates {
+			return false
+		}
+	case EventArbitrage:
+		if !f.Arbitrage {
+			return false
+		}
+	}
+
+	symbol, exchange := e.Series()
+	if len(f.Symbols) > 0 && !f.Symbols[symbol] {
+		return false
+	}
+	if e.Type != EventArbitrage && len(f.Exchanges) > 0 && !f.Exchanges[exchange] {
+		return false
+	}
+	return true
+}
+
+// Subscriber receives matching events on C. C is closed when the subscriber
+// is removed; Dropped then reports whether it was cut off for falling behind.
+type Subscriber struct {
+	C       <-chan Event
+	ch      chan Event
+	filter  Filter
+	closed  bool
+	dropped bool
+}
+
+func (s *Subscriber) Dropped() bool {
+	return s.dropped
+}
+
+// Broadcaster fans live events out to subscribers. Publishing never blocks:
+// a subscriber whose buffer is full is disconnected as a slow consumer. The
+// last event of every series is kept so new subscribers can get a snapshot.
+type Broadcaster struct {
+	mu   sync.RWMutex
+	subs map[*Subscriber]struct{}
+
+	stateMu   sync.Mutex
+	last      map[string]Event
+	arbitrage *arbitrageDetector
+}
+
+func NewBroadcaster(arbitrageThresholdBps float64) *Broadcaster {
+	return &Broadcaster{
+		subs:      make(map[*Subscriber]struct{}),
+		last:      make(map[string]Event),
+		arbitrage: newArbitrageDetector(arbitrageThresholdBps),
+	}
+}
+
+func (b *Broadcaster) Subscribe(filter Filter, buffer int) *Subscriber {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscriber{C: ch, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	total := len(b.subs)
+	b.mu.Unlock()
+
+	slog.Info("Stream subscriber added", "subscribers", total)
+	return sub
+}
+
+func (b *Broadcaster) Unsubscribe(sub *Subscriber) {
+	b.remove(sub, false)
+}
+
+// Close disconnects every subscriber, ending live streams on shutdown.
+func (b *Broadcaster) Close() {
+	b.mu.RLock()
+	subs := make([]*Subscriber, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		b.remove(sub, false)
+	}
+}
+
+// Snapshot returns the latest event of every series matching filter.
+func (b *Broadcaster) Snapshot(filter Filter) []Event {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+
+	var events []Event
+	for _, e := range b.last {
+		if filter.Match(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (b *Broadcaster) PublishTick(update domain.PriceUpdate) {
+	b.stateMu.Lock()
+	alert := b.arbitrage.observe(update, time.Now())
+	b.stateMu.Unlock()
+
+	b.publish(Event{Type: EventTick, Tick: &update})
+	if alert != nil {
+		slog.Info("Arbitrage opportunity detected", "symbol", alert.Symbol, "buy", alert.BuyExchange, "sell", alert.SellExchange, "spread_bps", alert.SpreadBps)
+		b.publish(Event{Type: EventArbitrage, Arbitrage: alert})
+	}
+}
+
+func (b *Broadcaster) PublishAggregate(agg domain.AggregatedResponse) {
+	b.publish(Event{Type: EventAggregate, Aggregate: &agg})
+}
+
+// Run publishes every update read from in as a tick event until in is closed
+// or ctx is done.
+func (b *Broadcaster) Run(ctx context.Context, in <-chan domain.PriceUpdate) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-in:
+			if !ok {
+				return
+			}
+			b.PublishTick(update)
+		}
+	}
+}
+
+func (b *Broadcaster) publish(e Event) {
+	symbol, exchange := e.Series()
+	b.stateMu.Lock()
+	b.last[e.Type+"|"+symbol+"|"+exchange] = e
+	b.stateMu.Unlock()
+
+	var slow []*Subscriber
+
+	b.mu.RLock()
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		b.remove(sub, true)
+	}
+}
+
+// remove closes the subscriber channel under the write lock, so it can never
+// race with a send made under the read lock.
+func (b *Broadcaster) remove(sub *Subscriber, dropped bool) {
+	b.mu.Lock()
+	if sub.closed {
+		b.mu.Unlock()
+		return
+	}
+	sub.closed = true
+	sub.dropped = dropped
+	delete(b.subs, sub)
+	close(sub.ch)
+	total := len(b.subs)
+	b.mu.Unlock()
+
+	if dropped {
+		slog.Warn("Stream subscriber disconnected as slow consumer", "subscribers", total)
+	} else {
+		slog.Info("Stream subscriber removed", "subscribers", total)
+	}
+}
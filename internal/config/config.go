@@ -0,0 +1,350 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"marketflow/internal/app/ingest"
+	"marketflow/internal/domain"
+	"marketflow/internal/logging"
+)
+
+const DefaultPath = "configs/config.yaml"
+
+type Config struct {
+	Storage   StorageConfig    `json:"storage"`
+	Postgres  PostgresConfig   `json:"postgres"`
+	Redis     RedisConfig      `json:"redis"`
+	Exchanges []ExchangeConfig `json:"exchanges"`
+	Symbols   []string         `json:"symbols"`
+	Mode      string           `json:"mode"`
+	Log       LogConfig        `json:"log"`
+
+	Aggregation AggregationConfig `json:"aggregation"`
+	Leader      LeaderConfig      `json:"leader"`
+	Rollups     RollupConfig      `json:"rollups"`
+	Admin       AdminConfig       `json:"admin"`
+	WebSocket   WebSocketConfig   `json:"websocket"`
+
+	// QueryTimeouts maps endpoint names (latest, highest, lowest, average,
+	// history, batch, coverage, export, import, or default) to a Go duration
+	// such as "2s"; "0" disables the limit.
+	QueryTimeouts map[string]string `json:"query_timeouts"`
+}
+
+// LogConfig sets the log level (debug, info, warn, error) and the output
+// format (text or json).
+type LogConfig struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// AggregationConfig sets how long raw ticks are kept in Redis, as a Go
+// duration. Windows missed for longer than this cannot be backfilled.
+type AggregationConfig struct {
+	TickRetention string `json:"tick_retention"`
+}
+
+// LeaderConfig sets the TTL of the aggregator lease, as a Go duration. A
+// replica that dies is replaced as leader within this time.
+type LeaderConfig struct {
+	LeaseTTL string `json:"lease_ttl"`
+}
+
+// RollupConfig maps each stored resolution (1m, 5m, 1h, 1d) to how long its
+// rows are kept, e.g. "7d"; "0" keeps them forever.
+type RollupConfig struct {
+	Retention map[string]string `json:"retention"`
+}
+
+// AdminConfig holds the bearer token of the admin endpoints, which are
+// disabled while it is empty. ADMIN_TOKEN takes precedence over the file.
+type AdminConfig struct {
+	Token string `json:"token"`
+}
+
+// WebSocketConfig lists the browser origins, such as
+// "https://dashboard.example.com", allowed to open /ws besides the service's
+// own host; "*" allows any origin.
+type WebSocketConfig struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// Storage backends: postgres for aggregates, redis for raw ticks, or memory
+// for either.
+const (
+	StoragePostgres = "postgres"
+	StorageRedis    = "redis"
+	StorageMemory   = "memory"
+)
+
+// StorageConfig selects where aggregates (Backend: postgres or memory) and raw
+// ticks and the leader lease (Ticks: redis or memory) are kept. Memory gives a
+// self-contained local run that loses its data on exit.
+type StorageConfig struct {
+	Backend string `json:"backend"`
+	Ticks   string `json:"ticks"`
+}
+
+// PostgresConfig locates the database. With Migrate set, pending schema
+// migrations are applied at startup.
+type PostgresConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	DBName   string `json:"dbname"`
+	Migrate  bool   `json:"migrate"`
+}
+
+type RedisConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+}
+
+// ExchangeConfig describes one price source. Backpressure is one of block,
+// drop-newest, drop-oldest or coalesce-latest-per-symbol and applies to the
+// exchange in both live and test mode; Buffer is its queue size in ticks.
+type ExchangeConfig struct {
+	Name         string `json:"name"`
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+	Backpressure string `json:"backpressure"`
+	Buffer       int    `json:"buffer"`
+}
+
+func Default() *Config {
+	cfg := &Config{
+		Storage:  StorageConfig{Backend: StoragePostgres, Ticks: StorageRedis},
+		Postgres: PostgresConfig{Host: "postgres", Port: 5432, User: "market", Password: "secret", DBName: "marketdb", Migrate: true},
+		Redis:    RedisConfig{Host: "redis", Port: 6379},
+		Symbols:  append([]string(nil), domain.TradingPairs...),
+		Mode:     "live",
+		Log:      LogConfig{Level: "info", Format: "text"},
+		Aggregation: AggregationConfig{
+			TickRetention: "15m",
+		},
+		Leader: LeaderConfig{LeaseTTL: "15s"},
+		Rollups: RollupConfig{Retention: map[string]string{
+			"1m": "7d",
+			"5m": "30d",
+			"1h": "365d",
+			"1d": "0",
+		}},
+		QueryTimeouts: map[string]string{
+			"default": "5s",
+			"history": "10s",
+			"batch":   "10s",
+			"export":  "5m",
+			"import":  "30m",
+		},
+	}
+	for i, name := range domain.ExchangeNames {
+		cfg.Exchanges = append(cfg.Exchanges, ExchangeConfig{Name: name, Host: name, Port: 40101 + i})
+	}
+	return cfg
+}
+
+// Load reads the config file at path over the defaults. CONFIG_PATH takes
+// precedence over path; a missing file at the default path is not an error.
+func Load(path string) (*Config, error) {
+	explicit := false
+	if env := os.Getenv("CONFIG_PATH"); env != "" {
+		path, explicit = env, true
+	} else if path != DefaultPath {
+		explicit = true
+	}
+
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			slog.Warn("Config file not found, using defaults", "path", path)
+			cfg.applyEnv()
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	tree, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := decodeYAML(tree, cfg); err != nil {
+		return nil, fmt.Errorf("decode config %s: %w", path, err)
+	}
+
+	cfg.applyEnv()
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	slog.Info("Config loaded", "path", path, "exchanges", len(cfg.Exchanges), "symbols", len(cfg.Symbols))
+	return cfg, nil
+}
+
+// applyEnv overrides secrets that are better kept out of the config file.
+func (c *Config) applyEnv() {
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		c.Admin.Token = token
+	}
+}
+
+func (c *Config) validate() error {
+	if len(c.Exchanges) == 0 {
+		return errors.New("at least one exchange must be configured")
+	}
+	if c.Storage.Backend != StoragePostgres && c.Storage.Backend != StorageMemory {
+		return fmt.Errorf("storage.backend: want postgres or memory, got %q", c.Storage.Backend)
+	}
+	if c.Storage.Ticks != StorageRedis && c.Storage.Ticks != StorageMemory {
+		return fmt.Errorf("storage.ticks: want redis or memory, got %q", c.Storage.Ticks)
+	}
+	if _, err := logging.New(io.Discard, c.Log.Level, c.Log.Format); err != nil {
+		return err
+	}
+	if _, err := c.Timeouts(); err != nil {
+		return err
+	}
+	if _, err := c.TickRetention(); err != nil {
+		return err
+	}
+	if _, err := c.LeaseTTL(); err != nil {
+		return err
+	}
+	if _, err := c.Resolutions(); err != nil {
+		return err
+	}
+	if len(c.Symbols) == 0 {
+		return errors.New("at least one symbol must be configured")
+	}
+	for _, ex := range c.Exchanges {
+		if ex.Name == "" {
+			return errors.New("exchange name must not be empty")
+		}
+		if _, err := ingest.ParsePolicy(ex.Backpressure); err != nil {
+			return fmt.Errorf("exchange %s: %w", ex.Name, err)
+		}
+		if ex.Buffer < 0 {
+			return fmt.Errorf("exchange %s: buffer must not be negative", ex.Name)
+		}
+	}
+	return nil
+}
+
+// Registry builds the canonical exchange and symbol registry from the config.
+func (c *Config) Registry() *domain.Registry {
+	names := make([]string, 0, len(c.Exchanges))
+	for _, ex := range c.Exchanges {
+		names = append(names, ex.Name)
+	}
+	return domain.NewRegistry(names, c.Symbols)
+}
+
+// Timeouts parses the configured query timeouts.
+func (c *Config) Timeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(c.QueryTimeouts))
+	for endpoint, raw := range c.QueryTimeouts {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("query_timeouts.%s: invalid duration %q", endpoint, raw)
+		}
+		timeouts[endpoint] = d
+	}
+	return timeouts, nil
+}
+
+// TickRetention parses aggregation.tick_retention. It must cover at least two
+// windows so the previous minute is still in Redis when it is aggregated.
+func (c *Config) TickRetention() (time.Duration, error) {
+	d, err := time.ParseDuration(c.Aggregation.TickRetention)
+	if err != nil || d < 2*time.Minute {
+		return 0, fmt.Errorf("aggregation.tick_retention: want a duration of at least 2m, got %q", c.Aggregation.TickRetention)
+	}
+	return d, nil
+}
+
+// LeaseTTL parses leader.lease_ttl. The lease is renewed every third of its
+// TTL, so anything much shorter than a few seconds would flap.
+func (c *Config) LeaseTTL() (time.Duration, error) {
+	d, err := time.ParseDuration(c.Leader.LeaseTTL)
+	if err != nil || d < 3*time.Second {
+		return 0, fmt.Errorf("leader.lease_ttl: want a duration of at least 3s, got %q", c.Leader.LeaseTTL)
+	}
+	return d, nil
+}
+
+// Resolutions applies the configured retention to the stored resolutions.
+// A resolution must be kept for at least two buckets of the next coarser one,
+// which is rolled up from it.
+func (c *Config) Resolutions() ([]domain.Resolution, error) {
+	resolutions := domain.DefaultResolutions()
+	known := make(map[string]bool, len(resolutions))
+	for _, r := range resolutions {
+		known[r.Name] = true
+	}
+	for name := range c.Rollups.Retention {
+		if !known[name] {
+			return nil, fmt.Errorf("rollups.retention.%s: unknown resolution, want 1m, 5m, 1h or 1d", name)
+		}
+	}
+
+	for i := range resolutions {
+		r := &resolutions[i]
+		raw, ok := c.Rollups.Retention[r.Name]
+		if !ok {
+			continue
+		}
+		if raw == "0" {
+			r.Retention = 0
+			continue
+		}
+		d, err := domain.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("rollups.retention.%s: invalid duration %q", r.Name, raw)
+		}
+		r.Retention = d
+	}
+	for i := 0; i+1 < len(resolutions); i++ {
+		r, next := resolutions[i], resolutions[i+1]
+		if r.Retention != 0 && r.Retention < 2*next.Interval {
+			return nil, fmt.Errorf("rollups.retention.%s: must be at least %s so %s rollups can be built", r.Name, 2*next.Interval, next.Name)
+		}
+	}
+	return resolutions, nil
+}
+
+// Sources returns the backpressure setup of every configured exchange.
+func (c *Config) Sources() []ingest.SourceConfig {
+	sources := make([]ingest.SourceConfig, 0, len(c.Exchanges))
+	for _, ex := range c.Exchanges {
+		policy, _ := ingest.ParsePolicy(ex.Backpressure)
+		sources = append(sources, ingest.SourceConfig{
+			Exchange: domain.CanonicalExchange(ex.Name),
+			Policy:   policy,
+			Buffer:   ex.Buffer,
+		})
+	}
+	return sources
+}
+
+func (c PostgresConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		c.Host, c.Port, c.User, c.Password, c.DBName)
+}
+
+func (c RedisConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+func (c ExchangeConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadBareZeroDurations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `query_timeouts:
+  default: 0
+  export: 2m
+rollups:
+  retention:
+    1d: 0
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_PATH", "")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	timeouts, err := cfg.Timeouts()
+	if err != nil {
+		t.Fatalf("Timeouts: %v", err)
+	}
+	if d, ok := timeouts["default"]; !ok || d != 0 {
+		t.Errorf("default timeout = %v (set %v), want 0", d, ok)
+	}
+	if timeouts["export"] != 2*time.Minute {
+		t.Errorf("export timeout = %v, want 2m", timeouts["export"])
+	}
+	if got := cfg.Rollups.Retention["1d"]; got != "0" {
+		t.Errorf("1d retention = %q, want \"0\"", got)
+	}
+}
+
+func TestLoadNumericLookingStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `postgres:
+  port: 5433
+  password: 12345
+  dbname: 2024
+redis:
+  password: 0042
+admin:
+  token: 0042
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_PATH", "")
+	t.Setenv("ADMIN_TOKEN", "")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p := cfg.Postgres; p.Port != 5433 || p.Password != "12345" || p.DBName != "2024" || p.Host != "postgres" {
+		t.Errorf("postgres = %+v, want port 5433, password 12345 and dbname 2024 over the defaults", p)
+	}
+	if cfg.Redis.Password != "0042" || cfg.Admin.Token != "0042" {
+		t.Errorf("redis password %q, admin token %q; want both \"0042\"", cfg.Redis.Password, cfg.Admin.Token)
+	}
+}
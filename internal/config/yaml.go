@@ -0,0 +1,320 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This file implements the small YAML subset used by configs/config.yaml:
+// block mappings, block sequences (of scalars or mappings), flow sequences of
+// scalars, quoted and plain scalars, and comments. Anchors, nested sequences,
+// multi-line strings and flow mappings are not supported.
+//
+// Scalars are parsed as text and only converted by decodeYAML, once the
+// field they fill is known, so a password of 12345 or a token of 0042 stays
+// the string it was written as.
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func parseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		text = stripComment(text)
+		if text == "" || text == "---" {
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(raw) - len(strings.TrimLeft(raw, " ")), text: text})
+	}
+	if len(p.lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	v, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return v, nil
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isListItem(p.lines[p.pos].text) {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if isListItem(line.text) {
+			break
+		}
+
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if rest != "" {
+			v, err := parseScalarOrFlow(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			m[key] = v
+			continue
+		}
+
+		m[key] = nil
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isListItem(next.text)) {
+				v, err := p.parseBlock(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+			}
+		}
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseList(indent int) (interface{}, error) {
+	var list []interface{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || !isListItem(line.text) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+
+		content := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if content == "" {
+			return nil, fmt.Errorf("line %d: expected a value after \"-\"", line.num)
+		}
+
+		if _, _, ok := splitKey(content); ok {
+			// "- key: value" starts a mapping whose keys are aligned with
+			// the first key, so re-read the line as a mapping entry.
+			itemIndent := indent + len(line.text) - len(content)
+			p.lines[p.pos] = yamlLine{num: line.num, indent: itemIndent, text: content}
+			v, err := p.parseMap(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			continue
+		}
+
+		v, err := parseScalarOrFlow(content)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		list = append(list, v)
+		p.pos++
+	}
+	return list, nil
+}
+
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits "key: value" into its parts. Quoted scalars are never keys.
+func splitKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") || strings.HasPrefix(text, "[") {
+		return "", "", false
+	}
+	idx := strings.Index(text, ": ")
+	if idx < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		idx = len(text) - 1
+	}
+	key := strings.TrimSpace(text[:idx])
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[idx+1:]), true
+}
+
+func stripComment(text string) string {
+	inSingle, inDouble := false, false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '#' && !inSingle && !inDouble && (i == 0 || text[i-1] == ' '):
+			return strings.TrimRight(text[:i], " ")
+		}
+	}
+	return text
+}
+
+func parseScalarOrFlow(s string) (interface{}, error) {
+	if !strings.HasPrefix(s, "[") {
+		return parseScalar(s)
+	}
+	if !strings.HasSuffix(s, "]") {
+		return nil, errors.New("unterminated flow sequence")
+	}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	list := []interface{}{}
+	if inner == "" {
+		return list, nil
+	}
+	for _, item := range strings.Split(inner, ",") {
+		v, err := parseScalar(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func parseScalar(s string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+
+	switch s {
+	case "", "~", "null":
+		return nil, nil
+	}
+	return s, nil
+}
+
+// decodeYAML stores a tree from parseYAML in the struct dst points to,
+// matching keys to json tags. Null values and keys missing from the tree
+// leave the field as it was, maps gain the keys of the tree, and unknown
+// keys are ignored.
+func decodeYAML(tree interface{}, dst interface{}) error {
+	return decodeValue(tree, reflect.ValueOf(dst).Elem(), "")
+}
+
+func decodeValue(node interface{}, v reflect.Value, path string) error {
+	if node == nil {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := node.(string)
+		if !ok {
+			return fmt.Errorf("%s: want a string", path)
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		switch node {
+		case "true":
+			v.SetBool(true)
+		case "false":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("%s: want true or false, got %v", path, node)
+		}
+	case reflect.Int, reflect.Int64:
+		s, _ := node.(string)
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return fmt.Errorf("%s: want an integer, got %v", path, node)
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		list, ok := node.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want a list", path)
+		}
+		out := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeValue(item, out.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(out)
+	case reflect.Map:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want a mapping", path)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		for key, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(item, elem, joinPath(path, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+	case reflect.Struct:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want a mapping", path)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			if item, ok := m[name]; ok && name != "" && name != "-" {
+				if err := decodeValue(item, v.Field(i), joinPath(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%s: cannot decode into %s", path, v.Type())
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
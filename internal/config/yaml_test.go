@@ -0,0 +1,143 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type m = map[string]interface{}
+type l = []interface{}
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want interface{}
+	}{
+		{"empty", "# only a comment\n---\n", m{}},
+		{"scalars stay text", "i: 42\nneg: -3\nf: 1.5\nt: true\nz: 0042\nn: ~\nn2: null\ns: plain text\nd: 15m\n",
+			m{"i": "42", "neg": "-3", "f": "1.5", "t": "true", "z": "0042", "n": nil, "n2": nil, "s": "plain text", "d": "15m"}},
+		{"quoting", "a: \"0\"\nb: 'it''s'\nc: \"tab\\there\"\nd: \"x # not a comment\"\ne: 'true'\n",
+			m{"a": "0", "b": "it's", "c": "tab\there", "d": "x # not a comment", "e": "true"}},
+		{"comments", "a: 1 # trailing\nb: x#y\n# full line\nc: 2\n",
+			m{"a": "1", "b": "x#y", "c": "2"}},
+		{"nesting", "postgres:\n  host: db\n  pool:\n    max: 10\nmode: live\n",
+			m{"postgres": m{"host": "db", "pool": m{"max": "10"}}, "mode": "live"}},
+		{"empty value", "token:\nnext: 1\n", m{"token": nil, "next": "1"}},
+		{"scalar list", "symbols:\n  - BTCUSDT\n  - ETHUSDT\n", m{"symbols": l{"BTCUSDT", "ETHUSDT"}}},
+		{"list at key indent", "symbols:\n- a\n- b\nmode: x\n", m{"symbols": l{"a", "b"}, "mode": "x"}},
+		{"flow list", "a: [1, two, \"3\"]\nb: []\n", m{"a": l{"1", "two", "3"}, "b": l{}}},
+		{"list of maps", "exchanges:\n  - name: exchange1\n    port: 40101\n  - name: exchange2\n    port: 40102\n",
+			m{"exchanges": l{m{"name": "exchange1", "port": "40101"}, m{"name": "exchange2", "port": "40102"}}}},
+		{"crlf", "a: 1\r\nb: 2\r\n", m{"a": "1", "b": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseYAML = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"tab indent", "a:\n\tb: 1\n", "line 2: tabs are not allowed"},
+		{"over-indented key", "a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"not a mapping", "a: 1\njust text\n", "line 2: expected \"key: value\""},
+		{"duplicate key", "a: 1\nb: 2\na: 3\n", "line 3: duplicate key \"a\""},
+		{"bad double quote", "a: 1\nb: \"open\n", "line 2: invalid quoted string"},
+		{"bad single quote", "a: 'open\n", "line 1: invalid quoted string"},
+		{"unterminated flow", "x: 1\n\ny: [1, 2\n", "line 3: unterminated flow sequence"},
+		{"list item over-indented", "a:\n  - 1\n    - 2\n", "line 3: unexpected indentation"},
+		{"dedent below root", "  a: 1\nb: 2\n", "line 2: unexpected indentation"},
+		{"nested list", "a:\n  -\n    - 1\n", "line 2: expected a value after \"-\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.in))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("parseYAML error = %v, want prefix %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeYAML(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	type target struct {
+		Password string            `json:"password"`
+		Token    string            `json:"token"`
+		Enabled  bool              `json:"enabled"`
+		Kept     string            `json:"kept"`
+		Items    []item            `json:"items"`
+		Limits   map[string]string `json:"limits"`
+		Skipped  string            `json:"-"`
+	}
+	in := "password: 12345\ntoken: 0042\nenabled: true\nkept: ~\nunknown: 1\n" +
+		"items:\n  - name: 2024\n    port: 5432\nlimits:\n  b: 0\n"
+	tree, err := parseYAML([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := target{Kept: "default", Limits: map[string]string{"a": "1s"}}
+	if err := decodeYAML(tree, &got); err != nil {
+		t.Fatalf("decodeYAML: %v", err)
+	}
+	want := target{
+		Password: "12345",
+		Token:    "0042",
+		Enabled:  true,
+		Kept:     "default",
+		Items:    []item{{Name: "2024", Port: 5432}},
+		Limits:   map[string]string{"a": "1s", "b": "0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeYAML = %+v, want %+v", got, want)
+	}
+
+	for in, want := range map[string]string{
+		"items:\n  - port: 54x\n": "items[0].port: want an integer",
+		"enabled: yes\n":          "enabled: want true or false",
+		"password: [1]\n":         "password: want a string",
+		"items: 1\n":              "items: want a list",
+		"limits: [a]\n":           "limits: want a mapping",
+	} {
+		tree, err := parseYAML([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := decodeYAML(tree, &target{}); err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("decodeYAML(%q) error = %v, want prefix %q", in, err, want)
+		}
+	}
+}
+
+func TestLoadShippedConfig(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "")
+	cfg, err := Load("../../configs/config.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Exchanges) == 0 || len(cfg.Symbols) == 0 {
+		t.Errorf("config has %d exchanges and %d symbols, want some of each", len(cfg.Exchanges), len(cfg.Symbols))
+	}
+	if _, err := cfg.Timeouts(); err != nil {
+		t.Errorf("Timeouts: %v", err)
+	}
+	if _, err := cfg.Resolutions(); err != nil {
+		t.Errorf("Resolutions: %v", err)
+	}
+}
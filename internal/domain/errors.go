@@ -0,0 +1,61 @@
+package domain
+
+import "errors"
+
+// Error kinds. Every *Error wraps exactly one of these, so callers can branch
+// with errors.Is without caring about the specific code.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrUnavailable     = errors.New("unavailable")
+	ErrCanceled        = errors.New("canceled")
+)
+
+// Error is a typed domain error carrying a stable machine-readable code and
+// optional details for API clients.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// WithDetail attaches a key/value pair that is returned to the client.
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+func NewNotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func NewInvalidArgument(code, message string) *Error {
+	return &Error{Kind: ErrInvalidArgument, Code: code, Message: message}
+}
+
+func NewUnavailable(code, message string, err error) *Error {
+	return &Error{Kind: ErrUnavailable, Code: code, Message: message, Err: err}
+}
+
+func NewCanceled(code, message string, err error) *Error {
+	return &Error{Kind: ErrCanceled, Code: code, Message: message, Err: err}
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 var TradingPairs = []string{
 	"BTCUSDT",
 	"ETHUSDT",
@@ -9,18 +11,26 @@ var TradingPairs = []string{
 }
 
 var ExchangeNames = []string{
-	"Exchange1",
-	"Exchange2",
-	"Exchange3",
+	"exchange1",
+	"exchange2",
+	"exchange3",
 }
 
 type PriceUpdate struct {
-	Symbol    string
-	Price     float64
-	Timestamp int64
-	Exchange  string
+	Symbol    string  `json:"symbol"`
+	Price     float64 `json:"price"`
+	Timestamp int64   `json:"timestamp"`
+	Exchange  string  `json:"exchange"`
+}
+
+// PriceKey is the Redis sorted-set key holding recent ticks for a pair on an
+// exchange.
+func PriceKey(symbol, exchange string) string {
+	return "price:" + symbol + ":" + exchange
 }
 
+// AggregatedResponse is one aggregate row. Interval names the resolution of
+// an exported row (1m, 5m, 1h or 1d) and is left empty elsewhere.
 type AggregatedResponse struct {
 	Pair      string  `json:"pair"`
 	Exchange  string  `json:"exchange"`
@@ -28,4 +38,82 @@ type AggregatedResponse struct {
 	Avg       float64 `json:"avg"`
 	Min       float64 `json:"min"`
 	Max       float64 `json:"max"`
+	Interval  string  `json:"interval,omitempty"`
+}
+
+type HistoryQuery struct {
+	Exchange string
+	Symbol   string
+	Range    TimeRange
+	AfterTS  time.Time
+	AfterID  int64
+	Limit    int
+}
+
+// ExportQuery selects the stored aggregates of one pair, and optionally one
+// exchange, over Range at Range.Resolution. At most Limit rows are returned.
+type ExportQuery struct {
+	Exchange string
+	Symbol   string
+	Range    TimeRange
+	Limit    int
+}
+
+type HistoryRecord struct {
+	ID        int64
+	Timestamp time.Time
+	Price     AggregatedResponse
+}
+
+type HistoryPage struct {
+	Items      []AggregatedResponse `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+type Coverage struct {
+	Exchange       string `json:"exchange"`
+	Symbol         string `json:"symbol"`
+	FirstAggregate string `json:"first_aggregate,omitempty"`
+	LastAggregate  string `json:"last_aggregate,omitempty"`
+	Rows           int64  `json:"rows"`
+	LatestTick     string `json:"latest_tick,omitempty"`
+}
+
+const (
+	StatLatest  = "latest"
+	StatHighest = "highest"
+	StatLowest  = "lowest"
+	StatAverage = "average"
+)
+
+// SeriesKey identifies a price series. An empty Exchange means the symbol
+// across all exchanges.
+type SeriesKey struct {
+	Exchange string
+	Symbol   string
+}
+
+type BatchItem struct {
+	Symbol   string `json:"symbol"`
+	Exchange string `json:"exchange,omitempty"`
+	Stat     string `json:"stat"`
+	Period   string `json:"period,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+}
+
+type BatchResult struct {
+	Item BatchItem
+	Data *AggregatedResponse
+	Err  error
+}
+
+type ArbitrageAlert struct {
+	Symbol       string  `json:"symbol"`
+	BuyExchange  string  `json:"buy_exchange"`
+	BuyPrice     float64 `json:"buy_price"`
+	SellExchange string  `json:"sell_exchange"`
+	SellPrice    float64 `json:"sell_price"`
+	SpreadBps    float64 `json:"spread_bps"`
+	Timestamp    string  `json:"timestamp"`
 }
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	exchangePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+	symbolPattern   = regexp.MustCompile(`^[A-Z0-9]{2,20}$`)
+)
+
+// Registry holds the known exchanges and symbols. Exchange IDs are
+// canonically lower-case and symbols upper-case.
+type Registry struct {
+	exchanges []string
+	symbols   []string
+}
+
+func NewRegistry(exchanges, symbols []string) *Registry {
+	r := &Registry{}
+	for _, ex := range exchanges {
+		r.exchanges = appendUnique(r.exchanges, CanonicalExchange(ex))
+	}
+	for _, sym := range symbols {
+		r.symbols = appendUnique(r.symbols, CanonicalSymbol(sym))
+	}
+	return r
+}
+
+func DefaultRegistry() *Registry {
+	return NewRegistry(ExchangeNames, TradingPairs)
+}
+
+func CanonicalExchange(exchange string) string {
+	return strings.ToLower(strings.TrimSpace(exchange))
+}
+
+func CanonicalSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+func (r *Registry) Exchanges() []string {
+	return append([]string(nil), r.exchanges...)
+}
+
+func (r *Registry) Symbols() []string {
+	return append([]string(nil), r.symbols...)
+}
+
+// Exchange returns the canonical form of exchange, or an invalid-argument
+// error for malformed input and a not-found error for unknown exchanges.
+func (r *Registry) Exchange(exchange string) (string, error) {
+	ex := CanonicalExchange(exchange)
+	if ex == "" {
+		return "", NewInvalidArgument("exchange_required", "exchange must not be empty")
+	}
+	if !exchangePattern.MatchString(ex) {
+		return "", NewInvalidArgument("invalid_exchange", "malformed exchange: "+exchange).
+			WithDetail("exchange", exchange).
+			WithDetail("valid_exchanges", r.Exchanges())
+	}
+	if !contains(r.exchanges, ex) {
+		return "", NewNotFound("unknown_exchange", "unknown exchange: "+ex).
+			WithDetail("exchange", ex).
+			WithDetail("valid_exchanges", r.Exchanges())
+	}
+	return ex, nil
+}
+
+// Symbol returns the canonical form of symbol, or an invalid-argument error
+// for malformed input and a not-found error for unknown symbols.
+func (r *Registry) Symbol(symbol string) (string, error) {
+	sym := CanonicalSymbol(symbol)
+	if sym == "" {
+		return "", NewInvalidArgument("symbol_required", "symbol cannot be empty")
+	}
+	if !symbolPattern.MatchString(sym) {
+		return "", NewInvalidArgument("invalid_symbol", "malformed symbol: "+symbol).
+			WithDetail("symbol", symbol).
+			WithDetail("valid_symbols", r.Symbols())
+	}
+	if !contains(r.symbols, sym) {
+		return "", NewNotFound("unknown_symbol", "unknown symbol: "+sym).
+			WithDetail("symbol", sym).
+			WithDetail("valid_symbols", r.Symbols())
+	}
+	return sym, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" || contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
@@ -0,0 +1,93 @@
+package domain
+
+import "time"
+
+// Resolution is one granularity of stored aggregates. Minute rows are written
+// by the aggregator; coarser rows are rolled up from the next finer one.
+// Rows older than Retention are deleted; zero keeps them forever.
+type Resolution struct {
+	Name      string
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+// MinResolutionBuckets is how many rows of a resolution a bounded range must
+// span before the API answers from it instead of a finer one.
+const MinResolutionBuckets = 12
+
+// DefaultResolutions lists the stored resolutions from finest to coarsest.
+func DefaultResolutions() []Resolution {
+	return []Resolution{
+		{Name: "1m", Interval: time.Minute, Retention: 7 * 24 * time.Hour},
+		{Name: "5m", Interval: 5 * time.Minute, Retention: 30 * 24 * time.Hour},
+		{Name: "1h", Interval: time.Hour, Retention: 365 * 24 * time.Hour},
+		{Name: "1d", Interval: 24 * time.Hour},
+	}
+}
+
+// ChooseResolution returns the interval of the coarsest resolution that
+// answers rng exactly: its rows start and end on the range bounds, still
+// cover the start of the range, and number at least MinResolutionBuckets.
+// When none qualifies, the finest resolution still holding the start of the
+// range is used. A range without a start is answered from the resolution
+// that keeps rows longest, so it covers all stored history. resolutions are
+// ordered from finest to coarsest.
+func ChooseResolution(resolutions []Resolution, rng TimeRange, now time.Time) time.Duration {
+	if len(resolutions) == 0 {
+		return time.Minute
+	}
+	if rng.From.IsZero() {
+		return longestKept(resolutions, rng.To)
+	}
+	for i := len(resolutions) - 1; i >= 0; i-- {
+		r := resolutions[i]
+		if aligned(rng.From, r.Interval) && aligned(rng.To, r.Interval) && retains(r, rng.From, now) &&
+			(rng.To.IsZero() || rng.To.Sub(rng.From) >= MinResolutionBuckets*r.Interval) {
+			return r.Interval
+		}
+	}
+	for _, r := range resolutions {
+		if retains(r, rng.From, now) {
+			return r.Interval
+		}
+	}
+	return resolutions[len(resolutions)-1].Interval
+}
+
+// longestKept returns the finest resolution among those kept longest that
+// ends on to. When none does, the finest of them is used and the bucket
+// holding to is read whole.
+func longestKept(resolutions []Resolution, to time.Time) time.Duration {
+	var kept []Resolution
+	for _, r := range resolutions {
+		switch {
+		case len(kept) == 0 || outlasts(r, kept[0]):
+			kept = []Resolution{r}
+		case !outlasts(kept[0], r):
+			kept = append(kept, r)
+		}
+	}
+	for _, r := range kept {
+		if aligned(to, r.Interval) {
+			return r.Interval
+		}
+	}
+	return kept[0].Interval
+}
+
+// outlasts reports whether a keeps rows longer than b.
+func outlasts(a, b Resolution) bool {
+	if a.Retention == 0 || b.Retention == 0 {
+		return a.Retention == 0 && b.Retention != 0
+	}
+	return a.Retention > b.Retention
+}
+
+func aligned(t time.Time, interval time.Duration) bool {
+	return t.IsZero() || t.UTC().Truncate(interval).Equal(t)
+}
+
+// retains reports whether r still holds rows from t.
+func retains(r Resolution, t time.Time, now time.Time) bool {
+	return r.Retention == 0 || !t.Before(now.Add(-r.Retention))
+}
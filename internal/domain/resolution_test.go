@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChooseResolution(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	resolutions := DefaultResolutions()
+
+	tests := []struct {
+		name string
+		rng  TimeRange
+		want time.Duration
+	}{
+		{"no period reads all history", TimeRange{}, 24 * time.Hour},
+		{"open start reads all history", TimeRange{To: day}, 24 * time.Hour},
+		{"open start, unaligned end", TimeRange{To: now}, 24 * time.Hour},
+		{"whole day reads hours", TimeRange{From: day, To: day.Add(24 * time.Hour)}, time.Hour},
+		{"unaligned day reads 5m", TimeRange{From: now.Add(-24 * time.Hour), To: now}, 5 * time.Minute},
+		{"unaligned 30m reads minutes", TimeRange{From: now.Add(-30*time.Minute + 7*time.Second), To: now}, time.Minute},
+		{"too few buckets falls back", TimeRange{From: day, To: day.Add(6 * time.Hour)}, 5 * time.Minute},
+		{"open end", TimeRange{From: day.AddDate(0, -1, 0)}, 24 * time.Hour},
+		{"past minute retention", TimeRange{From: day.AddDate(0, 0, -10), To: day.AddDate(0, 0, -10).Add(30 * time.Minute)}, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChooseResolution(resolutions, tt.rng, now); got != tt.want {
+				t.Errorf("ChooseResolution(%v .. %v) = %v, want %v", tt.rng.From, tt.rng.To, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRange is a half-open [From, To) interval. A zero bound means the range
+// is unbounded on that side.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+
+	// Resolution selects which stored rows answer the query: one-minute
+	// aggregates or one of their rollups. Zero means one minute.
+	Resolution time.Duration
+}
+
+func (r TimeRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+func (r TimeRange) Validate() error {
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return NewInvalidArgument("invalid_time_range", "'from' must be before 'to'")
+	}
+	return nil
+}
+
+// ParseTimestamp accepts either an RFC3339 timestamp or unix seconds.
+func ParseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid timestamp " + strconv.Quote(s) + ": expected RFC3339 or unix seconds")
+	}
+	return t.UTC(), nil
+}
+
+// ParseDuration extends time.ParseDuration with day ("d") and week ("w")
+// units, e.g. "1d", "2w", "1w2d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty duration")
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && (rest[i] == '.' || (rest[i] >= '0' && rest[i] <= '9')) {
+			i++
+		}
+		j := i
+		for j < len(rest) && !(rest[j] == '.' || (rest[j] >= '0' && rest[j] <= '9')) {
+			j++
+		}
+		if i == 0 || j == i {
+			return 0, errors.New("invalid duration " + strconv.Quote(s))
+		}
+
+		num, unit := rest[:i], rest[i:j]
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, errors.New("invalid duration " + strconv.Quote(s))
+			}
+			day := 24 * time.Hour
+			if unit == "w" {
+				day *= 7
+			}
+			total += time.Duration(n * float64(day))
+		default:
+			d, err := time.ParseDuration(num + unit)
+			if err != nil {
+				return 0, errors.New("invalid duration " + strconv.Quote(s))
+			}
+			total += d
+		}
+		rest = rest[j:]
+	}
+
+	if total <= 0 {
+		return 0, errors.New("duration must be positive")
+	}
+	return total, nil
+}
+
+// ParsePeriod resolves a period expression relative to now. It accepts a
+// duration (see ParseDuration), which yields [now-d, now), or one of the UTC
+// aligned windows: this_hour, last_hour, today, yesterday, this_week,
+// last_week, this_month, last_month.
+func ParsePeriod(s string, now time.Time) (TimeRange, error) {
+	now = now.UTC()
+	hour := now.Truncate(time.Hour)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	week := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "this_hour":
+		return TimeRange{From: hour, To: hour.Add(time.Hour)}, nil
+	case "last_hour":
+		return TimeRange{From: hour.Add(-time.Hour), To: hour}, nil
+	case "today":
+		return TimeRange{From: day, To: day.AddDate(0, 0, 1)}, nil
+	case "yesterday":
+		return TimeRange{From: day.AddDate(0, 0, -1), To: day}, nil
+	case "this_week":
+		return TimeRange{From: week, To: week.AddDate(0, 0, 7)}, nil
+	case "last_week":
+		return TimeRange{From: week.AddDate(0, 0, -7), To: week}, nil
+	case "this_month":
+		return TimeRange{From: month, To: month.AddDate(0, 1, 0)}, nil
+	case "last_month":
+		return TimeRange{From: month.AddDate(0, -1, 0), To: month}, nil
+	}
+
+	d, err := ParseDuration(s)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	return TimeRange{From: now.Add(-d), To: now}, nil
+}
+
+// ResolveTimeRange combines optional period, from and to expressions into a
+// validated range. A period is anchored at to when given, otherwise at now.
+func ResolveTimeRange(period, from, to string, now time.Time) (TimeRange, error) {
+	var rng TimeRange
+	var err error
+
+	if from != "" {
+		if rng.From, err = ParseTimestamp(from); err != nil {
+			return TimeRange{}, NewInvalidArgument("invalid_time_range", "invalid 'from': "+err.Error()).WithDetail("parameter", "from")
+		}
+	}
+	if to != "" {
+		if rng.To, err = ParseTimestamp(to); err != nil {
+			return TimeRange{}, NewInvalidArgument("invalid_time_range", "invalid 'to': "+err.Error()).WithDetail("parameter", "to")
+		}
+	}
+
+	if period != "" {
+		if !rng.From.IsZero() {
+			return TimeRange{}, NewInvalidArgument("invalid_time_range", "'period' cannot be combined with 'from'")
+		}
+		anchor := now
+		if !rng.To.IsZero() {
+			anchor = rng.To
+		}
+		if rng, err = ParsePeriod(period, anchor); err != nil {
+			return TimeRange{}, NewInvalidArgument("invalid_time_range", "invalid 'period': "+err.Error()).WithDetail("parameter", "period")
+		}
+	}
+
+	if err := rng.Validate(); err != nil {
+		return TimeRange{}, err
+	}
+	return rng, nil
+}
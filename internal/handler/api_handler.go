@@ -1,51 +1,101 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
-	"strings"
+	"time"
 
 	"marketflow/internal/app/api"
+	"marketflow/internal/app/importer"
 	"marketflow/internal/app/mode"
+	"marketflow/internal/app/stream"
+	"marketflow/internal/domain"
 )
 
 type Handler struct {
 	Service     *api.APIService
 	ModeManager *mode.Manager
+	Stream      *stream.Broadcaster
+	Timeouts    map[string]time.Duration
+
+	// Importer serves POST /admin/import to callers presenting AdminToken.
+	// The endpoint is disabled while either is unset.
+	Importer   *importer.Importer
+	AdminToken string
+
+	// AllowedOrigins are the browser origins besides the request's own host
+	// that may open a WebSocket; "*" allows any.
+	AllowedOrigins []string
 }
 
+// StatusClientClosedRequest is the non-standard status logged when the client
+// goes away before its query completes.
+const StatusClientClosedRequest = 499
+
+// DefaultQueryTimeout applies to endpoints without a configured timeout.
+const DefaultQueryTimeout = 5 * time.Second
+
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string                 `json:"error"`
+	Code      string                 `json:"code"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
-func NewHandler(service *api.APIService, mm *mode.Manager) *Handler {
-	return &Handler{Service: service, ModeManager: mm}
+// NewHandler builds the API handler. timeouts maps endpoint names (latest,
+// highest, lowest, average, history, batch, coverage, export, import) to their
+// query timeout, with "default" for the rest; a zero timeout disables the limit.
+func NewHandler(service *api.APIService, mm *mode.Manager, broadcaster *stream.Broadcaster, timeouts map[string]time.Duration) *Handler {
+	return &Handler{Service: service, ModeManager: mm, Stream: broadcaster, Timeouts: timeouts}
 }
 
-func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-	if len(parts) == 5 {
-		h.HandleLatestByExchange(w, r)
+// queryContext derives the storage query context of an endpoint from the
+// request context, so a disconnecting client also cancels its query.
+func (h *Handler) queryContext(r *http.Request, endpoint string) (context.Context, context.CancelFunc) {
+	timeout, ok := h.Timeouts[endpoint]
+	if !ok {
+		timeout, ok = h.Timeouts["default"]
+	}
+	if !ok {
+		timeout = DefaultQueryTimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// Latest, Highest, Lowest and Average serve both the /{symbol} and the
+// /{exchange}/{symbol} routes and pick the variant from the path and query.
+func (h *Handler) Latest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("exchange") != "" {
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleLatestInRangeByExchange(w, r)
+		} else {
+			h.HandleLatestByExchange(w, r)
+		}
 	} else {
-		h.HandleLatestPrice(w, r)
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleLatestInRange(w, r)
+		} else {
+			h.HandleLatestPrice(w, r)
+		}
 	}
 }
 
 func (h *Handler) Highest(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-
-	if len(parts) == 5 {
-		if r.URL.Query().Has("period") {
-			h.HandleHighestByPeriodByExchange(w, r)
+	if r.PathValue("exchange") != "" {
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleHighestInRangeByExchange(w, r)
 		} else {
 			h.HandleHighestByExchange(w, r)
 		}
 	} else {
-		if r.URL.Query().Has("period") {
-			h.HandleHighestByPeriod(w, r)
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleHighestInRange(w, r)
 		} else {
 			h.HandleHighestPrice(w, r)
 		}
@@ -53,18 +103,15 @@ func (h *Handler) Highest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Lowest(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-
-	if len(parts) == 5 {
-		if r.URL.Query().Has("period") {
-			h.HandleLowestByPeriodByExchange(w, r)
+	if r.PathValue("exchange") != "" {
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleLowestInRangeByExchange(w, r)
 		} else {
 			h.HandleLowestByExchange(w, r)
 		}
 	} else {
-		if r.URL.Query().Has("period") {
-			h.HandleLowestByPeriod(w, r)
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleLowestInRange(w, r)
 		} else {
 			h.HandleLowestPrice(w, r)
 		}
@@ -72,23 +119,71 @@ func (h *Handler) Lowest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Average(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-
-	if len(parts) == 5 {
-		if r.URL.Query().Has("period") {
-			h.HandleAvgByPeriodByExchange(w, r)
+	if r.PathValue("exchange") != "" {
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleAvgInRangeByExchange(w, r)
 		} else {
 			h.HandleAvgByExchange(w, r)
 		}
 	} else {
-		h.HandleAvgPrice(w, r)
+		if hasTimeRange(r.URL.Query()) {
+			h.HandleAvgInRange(w, r)
+		} else {
+			h.HandleAvgPrice(w, r)
+		}
+	}
+}
+
+var (
+	errMethodNotAllowed = errors.New("method not allowed")
+	errUnauthorized     = errors.New("unauthorized")
+)
+
+// writeError maps a domain error kind to its HTTP status and writes the JSON
+// error body. Errors that are not *domain.Error are reported as internal.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, resp := errorResponse(r, err)
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "Request failed", "status", status, "code", resp.Code, "err", err)
+	} else {
+		slog.DebugContext(r.Context(), "Returning error", "status", status, "code", resp.Code, "message", resp.Error)
+	}
+	writeJSONError(w, status, resp)
+}
+
+func errorResponse(r *http.Request, err error) (int, ErrorResponse) {
+	resp := ErrorResponse{
+		Error:     "internal server error",
+		Code:      "internal",
+		RequestID: RequestIDFromContext(r.Context()),
+	}
+	status := http.StatusInternalServerError
+
+	var derr *domain.Error
+	if errors.As(err, &derr) {
+		resp.Error = derr.Message
+		resp.Code = derr.Code
+		resp.Details = derr.Details
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrCanceled):
+		status = StatusClientClosedRequest
+	case errors.Is(err, errMethodNotAllowed):
+		status = http.StatusMethodNotAllowed
+	case errors.Is(err, errUnauthorized):
+		status = http.StatusUnauthorized
 	}
+	return status, resp
 }
 
-func writeJSONError(w http.ResponseWriter, status int, message string) {
-	slog.Warn("Returning error", "status", status, "message", message)
+func writeJSONError(w http.ResponseWriter, status int, resp ErrorResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+	json.NewEncoder(w).Encode(resp)
 }
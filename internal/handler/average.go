@@ -4,97 +4,89 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strings"
-	"time"
 )
 
 func (h *Handler) HandleAvgPrice(w http.ResponseWriter, r *http.Request) {
-	symbol := strings.TrimPrefix(r.URL.Path, "/prices/average/")
-	slog.Info("HandleAvgPrice called", "symbol", symbol)
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleAvgPrice called", "symbol", symbol)
 
-	if symbol == "" {
-		writeJSONError(w, http.StatusBadRequest, "Symbol is required")
-		return
-	}
-
-	data, err := h.Service.GetAvgBySymbol(symbol)
+	ctx, cancel := h.queryContext(r, "average")
+	defer cancel()
+	data, err := h.Service.GetAvgBySymbol(ctx, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if data == nil {
-		writeJSONError(w, http.StatusNotFound, "No data found")
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("Responded with average price", "symbol", symbol, "data", data)
+	slog.DebugContext(r.Context(), "Responded with average price", "symbol", symbol, "data", data)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(data)
 }
 
 func (h *Handler) HandleAvgByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/average/")
-	slog.Info("HandleAvgByExchange called", "path", path)
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleAvgByExchange called", "exchange", exchange, "symbol", symbol)
 
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
-		writeJSONError(w, http.StatusBadRequest, "Invalid path. Format: /prices/average/{exchange}/{symbol}")
-		return
-	}
-
-	data, err := h.Service.GetAvgByExchange(path)
+	ctx, cancel := h.queryContext(r, "average")
+	defer cancel()
+	data, err := h.Service.GetAvgByExchange(ctx, exchange, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if data == nil {
-		writeJSONError(w, http.StatusNotFound, "No data found")
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("Responded with average price by exchange", "data", data)
+	slog.DebugContext(r.Context(), "Responded with average price by exchange", "data", data)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(data)
 }
 
-func (h *Handler) HandleAvgByPeriodByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/average/")
-	slog.Info("HandleAvgByPeriodByExchange called", "path", path)
+func (h *Handler) HandleAvgInRange(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleAvgInRange called", "symbol", symbol)
 
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
-		writeJSONError(w, http.StatusBadRequest, "Invalid path. Format: /prices/average/{exchange}/{symbol}")
+	rng, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	exchange := parts[0]
-	symbol := parts[1]
-
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing 'period' query parameter")
+	ctx, cancel := h.queryContext(r, "average")
+	defer cancel()
+	result, err := h.Service.GetAvgInRange(ctx, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	duration, err := time.ParseDuration(periodStr)
+	slog.DebugContext(r.Context(), "Responded with average price in range", "symbol", symbol, "from", rng.From, "to", rng.To)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handler) HandleAvgInRangeByExchange(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleAvgInRangeByExchange called", "exchange", exchange, "symbol", symbol)
+
+	rng, err := parseTimeRange(r.URL.Query())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Invalid period format: "+err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	result, err := h.Service.QueryAvgSinceByExchange(exchange, symbol, duration)
+	ctx, cancel := h.queryContext(r, "average")
+	defer cancel()
+	result, err := h.Service.GetAvgInRangeByExchange(ctx, exchange, symbol, rng)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-	if result == nil {
-		writeJSONError(w, http.StatusNotFound, "No data found")
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("Responded with average price by period", "exchange", exchange, "symbol", symbol, "period", duration)
+	slog.DebugContext(r.Context(), "Responded with average price in range by exchange", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(result)
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"marketflow/internal/domain"
+)
+
+const maxBatchBodyBytes = 1 << 20
+
+type BatchRequest struct {
+	Items []domain.BatchItem `json:"items"`
+}
+
+type BatchResultItem struct {
+	Symbol   string                     `json:"symbol"`
+	Exchange string                     `json:"exchange,omitempty"`
+	Stat     string                     `json:"stat"`
+	Status   int                        `json:"status"`
+	Data     *domain.AggregatedResponse `json:"data,omitempty"`
+	Error    *ErrorResponse             `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResultItem `json:"results"`
+}
+
+func (h *Handler) HandleBatchQuery(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "HandleBatchQuery called")
+
+	var req BatchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, r, domain.NewInvalidArgument("invalid_body", "Invalid request body: "+err.Error()))
+		return
+	}
+
+	ctx, cancel := h.queryContext(r, "batch")
+	defer cancel()
+	results, err := h.Service.QueryBatch(ctx, req.Items)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := BatchResponse{Results: make([]BatchResultItem, 0, len(results))}
+	for _, res := range results {
+		item := BatchResultItem{
+			Symbol:   res.Item.Symbol,
+			Exchange: res.Item.Exchange,
+			Stat:     res.Item.Stat,
+			Status:   http.StatusOK,
+			Data:     res.Data,
+		}
+		if res.Err != nil {
+			status, body := errorResponse(r, res.Err)
+			item.Status = status
+			item.Error = &body
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	slog.DebugContext(r.Context(), "HandleBatchQuery success", "items", len(resp.Results))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type ExchangesResponse struct {
+	Exchanges []string `json:"exchanges"`
+}
+
+type SymbolsResponse struct {
+	Symbols []string `json:"symbols"`
+}
+
+func (h *Handler) HandleExchanges(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "HandleExchanges called")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ExchangesResponse{Exchanges: h.Service.ListExchanges()})
+}
+
+func (h *Handler) HandleSymbols(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "HandleSymbols called")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(SymbolsResponse{Symbols: h.Service.ListSymbols()})
+}
+
+func (h *Handler) HandleCoverage(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "HandleCoverage called")
+
+	ctx, cancel := h.queryContext(r, "coverage")
+	defer cancel()
+	coverage, err := h.Service.GetCoverage(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	slog.DebugContext(r.Context(), "HandleCoverage success", "series", len(coverage))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(coverage)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"marketflow/internal/domain"
+)
+
+// HandleExport streams the stored aggregates of a symbol as CSV or NDJSON:
+// ?symbol= is required; ?exchange=, ?period=/?from=/?to=, ?resolution= and
+// ?limit= are optional. The body is gzip-compressed when the client accepts
+// it, and an export cut short by the row limit ends with the
+// X-Export-Truncated trailer.
+func (h *Handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	symbol, exchange := query.Get("symbol"), query.Get("exchange")
+	slog.DebugContext(r.Context(), "HandleExport called", "symbol", symbol, "exchange", exchange, "format", query.Get("format"))
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		writeError(w, r, domain.NewInvalidArgument("invalid_parameter", "Invalid 'format', expected csv or ndjson").WithDetail("parameter", "format"))
+		return
+	}
+	rng, err := parseTimeRange(query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, r, domain.NewInvalidArgument("invalid_parameter", "Invalid 'limit', expected a positive integer").WithDetail("parameter", "limit"))
+			return
+		}
+	}
+
+	// The response starts with the first row, so errors found before any
+	// data is sent still get a JSON error response.
+	var (
+		out  exportWriter
+		gz   *gzip.Writer
+		rows int
+	)
+	begin := func() {
+		var body io.Writer = w
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportFilename(symbol, format)+`"`)
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Set("Trailer", "X-Export-Truncated")
+		if headerContains(r.Header, "Accept-Encoding", "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz = gzip.NewWriter(w)
+			body = gz
+		}
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			out = newCSVExport(body)
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			out = ndjsonExport{json.NewEncoder(body)}
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ctx, cancel := h.queryContext(r, "export")
+	defer cancel()
+	truncated, err := h.Service.ExportAggregates(ctx, exchange, symbol, rng, query.Get("resolution"), limit, func(p domain.AggregatedResponse) error {
+		if out == nil {
+			begin()
+		}
+		rows++
+		return out.Write(p)
+	})
+	if err != nil && out == nil {
+		writeError(w, r, err)
+		return
+	}
+	if out == nil {
+		begin()
+	}
+	err = errors.Join(err, out.Close())
+	if gz != nil {
+		err = errors.Join(err, gz.Close())
+	}
+	if err != nil {
+		// The status is already sent; a truncated body is all the client
+		// can be told.
+		slog.WarnContext(r.Context(), "Export aborted", "symbol", symbol, "rows", rows, "err", err)
+		return
+	}
+	if truncated {
+		w.Header().Set("X-Export-Truncated", "true")
+	}
+	slog.DebugContext(r.Context(), "HandleExport success", "symbol", symbol, "rows", rows, "truncated", truncated)
+}
+
+// exportFilename names the download after the symbol. Only the characters a
+// symbol can hold are kept, so the header cannot be broken out of.
+func exportFilename(symbol, format string) string {
+	symbol = strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			return c
+		}
+		return -1
+	}, symbol)
+	return "aggregates-" + symbol + "." + format
+}
+
+type exportWriter interface {
+	Write(p domain.AggregatedResponse) error
+	Close() error
+}
+
+// csvExport writes through a small buffer, so rows reach the client as the
+// cursor yields them.
+type csvExport struct {
+	cw *csv.Writer
+}
+
+func newCSVExport(w io.Writer) csvExport {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"pair_name", "exchange", "window_start", "average_price", "min_price", "max_price", "interval"})
+	return csvExport{cw}
+}
+
+func (e csvExport) Write(p domain.AggregatedResponse) error {
+	_ = e.cw.Write([]string{
+		p.Pair,
+		p.Exchange,
+		p.Timestamp,
+		strconv.FormatFloat(p.Avg, 'f', -1, 64),
+		strconv.FormatFloat(p.Min, 'f', -1, 64),
+		strconv.FormatFloat(p.Max, 'f', -1, 64),
+		p.Interval,
+	})
+	return e.cw.Error()
+}
+
+func (e csvExport) Close() error {
+	e.cw.Flush()
+	return e.cw.Error()
+}
+
+type ndjsonExport struct {
+	enc *json.Encoder
+}
+
+func (e ndjsonExport) Write(p domain.AggregatedResponse) error { return e.enc.Encode(p) }
+
+func (e ndjsonExport) Close() error { return nil }
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/memory"
+	"marketflow/internal/app/api"
+	"marketflow/internal/domain"
+)
+
+func newExportHandler(t *testing.T, rows int) *Handler {
+	t.Helper()
+	store := memory.NewStore()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < rows; i++ {
+		if err := store.SaveAggregatedPrice(context.Background(), "BTCUSDT", "exchange1", start.Add(time.Duration(i)*time.Minute), 1, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := domain.NewRegistry([]string{"exchange1"}, []string{"BTCUSDT"})
+	return NewHandler(api.NewService(store, nil, registry, domain.DefaultResolutions()), nil, nil, nil)
+}
+
+func TestHandleExportLimit(t *testing.T) {
+	h := newExportHandler(t, 3)
+	tests := []struct {
+		query     string
+		rows      int
+		truncated bool
+	}{
+		{"symbol=btcusdt&format=ndjson", 3, false},
+		{"symbol=btcusdt&format=ndjson&limit=3", 3, false},
+		{"symbol=btcusdt&format=ndjson&limit=2", 2, true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/export/aggregates?"+tt.query, nil))
+		res := rec.Result()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.query, res.StatusCode, rec.Body)
+		}
+		if got := strings.Count(rec.Body.String(), "\n"); got != tt.rows {
+			t.Errorf("%s: %d rows, want %d", tt.query, got, tt.rows)
+		}
+		if got := res.Trailer.Get("X-Export-Truncated") == "true"; got != tt.truncated {
+			t.Errorf("%s: truncated trailer = %v, want %v", tt.query, got, tt.truncated)
+		}
+		if got := res.Header.Get("Content-Disposition"); got != `attachment; filename="aggregates-BTCUSDT.ndjson"` {
+			t.Errorf("%s: Content-Disposition = %s", tt.query, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/export/aggregates?symbol=BTCUSDT&limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("limit=0: status %d, want 400", rec.Code)
+	}
+}
+
+func TestExportFilename(t *testing.T) {
+	tests := map[string]string{
+		"BTCUSDT":              "aggregates-BTCUSDT.csv",
+		"btcusdt":              "aggregates-BTCUSDT.csv",
+		"x\"; filename=\"evil": "aggregates-XFILENAMEEVIL.csv",
+		"a\r\nSet-Cookie: b":   "aggregates-ASET-COOKIEB.csv",
+		"../..\\etc":           "aggregates-ETC.csv",
+	}
+	for symbol, want := range tests {
+		if got := exportFilename(symbol, "csv"); got != want {
+			t.Errorf("exportFilename(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+}
+
+func TestHandleExportNamesInterval(t *testing.T) {
+	h := newExportHandler(t, 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := memory.NewStore()
+	for i := 0; i < 10; i++ {
+		if err := store.SaveAggregatedPrice(context.Background(), "BTCUSDT", "exchange1", start.Add(time.Duration(i)*time.Minute), 1, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Rollup(context.Background(), time.Minute, 5*time.Minute, start, start.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	h.Service = api.NewService(store, nil, domain.NewRegistry([]string{"exchange1"}, []string{"BTCUSDT"}), domain.DefaultResolutions())
+
+	for query, want := range map[string]string{
+		"symbol=BTCUSDT&limit=1":                             "pair_name,exchange,window_start,average_price,min_price,max_price,interval\nBTCUSDT,exchange1,2024-01-01T00:00:00Z,1,1,1,1m\n",
+		"symbol=BTCUSDT&limit=1&resolution=5m":               "pair_name,exchange,window_start,average_price,min_price,max_price,interval\nBTCUSDT,exchange1,2024-01-01T00:00:00Z,1,1,1,5m\n",
+		"symbol=BTCUSDT&limit=1&resolution=5m&format=ndjson": `{"pair":"BTCUSDT","exchange":"exchange1","timestamp":"2024-01-01T00:00:00Z","avg":1,"min":1,"max":1,"interval":"5m"}` + "\n",
+	} {
+		rec := httptest.NewRecorder()
+		h.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/export/aggregates?"+query, nil))
+		if got := rec.Body.String(); got != want {
+			t.Errorf("%s: body = %q, want %q", query, got, want)
+		}
+	}
+}
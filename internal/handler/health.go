@@ -6,38 +6,69 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"marketflow/internal/app/fanout"
+	"marketflow/internal/app/ingest"
+	"marketflow/internal/app/leader"
 )
 
 type HealthHandler struct {
-	DB    DBChecker
-	Redis RedisChecker
+	DB       DBChecker
+	Storage  string
+	Ticks    string
+	Redis    RedisChecker
+	Pipeline PipelineStats
+	Ingest   IngestStats
+	Leader   LeaderStatus
 }
 
 type DBChecker interface {
-	Ping() error
+	Ping(ctx context.Context) error
 }
 
+const healthCheckTimeout = 2 * time.Second
+
 type RedisChecker interface {
 	Ping(ctx context.Context) error
 }
 
+// PipelineStats reports the fan-out queues between sources and consumers.
+type PipelineStats interface {
+	Received() uint64
+	Stats() []fanout.SubscriberStats
+}
+
+// IngestStats reports ticks the sources dropped or coalesced under
+// backpressure.
+type IngestStats interface {
+	Counters() []ingest.SeriesCounters
+}
+
+// LeaderStatus reports whether this replica is the one aggregating.
+type LeaderStatus interface {
+	Status() leader.Status
+}
+
 func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Health check started", "method", r.Method, "url", r.URL.Path)
+	slog.DebugContext(r.Context(), "Health check started", "method", r.Method, "url", r.URL.Path)
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
 
 	dbStatus := "ok"
-	if err := h.DB.Ping(); err != nil {
+	if err := h.DB.Ping(ctx); err != nil {
 		dbStatus = "disconnected"
-		slog.Warn("Database ping failed", "error", err)
+		slog.WarnContext(r.Context(), "Database ping failed", "error", err)
 	} else {
-		slog.Info("Database ping successful")
+		slog.DebugContext(r.Context(), "Database ping successful")
 	}
 
 	redisStatus := "ok"
-	if err := h.Redis.Ping(context.Background()); err != nil {
+	if err := h.Redis.Ping(ctx); err != nil {
 		redisStatus = "unavailable"
-		slog.Warn("Redis ping failed", "error", err)
+		slog.WarnContext(r.Context(), "Redis ping failed", "error", err)
 	} else {
-		slog.Info("Redis ping successful")
+		slog.DebugContext(r.Context(), "Redis ping successful")
 	}
 
 	response := map[string]interface{}{
@@ -46,11 +77,31 @@ func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"redis":     redisStatus,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
+	if h.Storage != "" {
+		response["storage"] = h.Storage
+	}
+	if h.Ticks != "" {
+		response["ticks"] = h.Ticks
+	}
+	if h.Pipeline != nil {
+		response["pipeline"] = map[string]interface{}{
+			"received":    h.Pipeline.Received(),
+			"subscribers": h.Pipeline.Stats(),
+		}
+	}
+	if h.Ingest != nil {
+		response["backpressure"] = h.Ingest.Counters()
+	}
+	if h.Leader != nil {
+		status := h.Leader.Status()
+		response["role"] = status.Role
+		response["leader"] = status
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		slog.Error("Failed to write health check response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write health check response", "error", err)
 	}
 
-	slog.Info("Health check completed", "db", dbStatus, "redis", redisStatus)
+	slog.DebugContext(r.Context(), "Health check completed", "db", dbStatus, "redis", redisStatus)
 }
@@ -4,109 +4,90 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strings"
-	"time"
 )
 
 func (h *Handler) HandleHighestPrice(w http.ResponseWriter, r *http.Request) {
-	symbol := strings.TrimPrefix(r.URL.Path, "/prices/highest/")
-	slog.Info("HandleHighestPrice called", "symbol", symbol)
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleHighestPrice called", "symbol", symbol)
 
-	data, err := h.Service.GetHighestBySymbol(symbol)
+	ctx, cancel := h.queryContext(r, "highest")
+	defer cancel()
+	data, err := h.Service.GetHighestBySymbol(ctx, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("GetHighestBySymbol success", "symbol", symbol)
+	slog.DebugContext(r.Context(), "GetHighestBySymbol success", "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(data)
 }
 
 func (h *Handler) HandleHighestByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/highest/")
-	slog.Info("HandleHighestByExchange called", "path", path)
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleHighestByExchange called", "exchange", exchange, "symbol", symbol)
 
-	data, err := h.Service.GetHighestByExchange(path)
+	ctx, cancel := h.queryContext(r, "highest")
+	defer cancel()
+	data, err := h.Service.GetHighestByExchange(ctx, exchange, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("GetHighestByExchange success", "path", path)
+	slog.DebugContext(r.Context(), "GetHighestByExchange success", "exchange", exchange, "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(data)
 }
 
-func (h *Handler) HandleHighestByPeriod(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/highest/")
-	slog.Info("HandleHighestByPeriod called", "path", path)
+func (h *Handler) HandleHighestInRange(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleHighestInRange called", "symbol", symbol)
 
-	parts := strings.Split(path, "/")
-	if len(parts) < 1 {
-		writeJSONError(w, http.StatusBadRequest, "Missing symbol")
-		return
-	}
-	symbol := parts[0]
-
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing 'period' query parameter")
-		return
-	}
-
-	duration, err := time.ParseDuration(periodStr)
+	rng, err := parseTimeRange(r.URL.Query())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Invalid period format: "+err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	result, err := h.Service.GetHighestByPeriod(symbol, duration)
+	ctx, cancel := h.queryContext(r, "highest")
+	defer cancel()
+	result, err := h.Service.GetHighestInRange(ctx, symbol, rng)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("GetHighestByPeriod success", "symbol", symbol, "period", duration)
+	slog.DebugContext(r.Context(), "Responded with highest price in range", "symbol", symbol, "from", rng.From, "to", rng.To)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(result)
+	_ = json.NewEncoder(w).Encode(result)
 }
 
-func (h *Handler) HandleHighestByPeriodByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/highest/")
-	slog.Info("HandleHighestByPeriodByExchange called", "path", path)
-
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
-		writeJSONError(w, http.StatusBadRequest, "Missing exchange or symbol")
-		return
-	}
-	exchange := parts[0]
-	symbol := parts[1]
-
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing 'period' query parameter")
-		return
-	}
+func (h *Handler) HandleHighestInRangeByExchange(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleHighestInRangeByExchange called", "exchange", exchange, "symbol", symbol)
 
-	duration, err := time.ParseDuration(periodStr)
+	rng, err := parseTimeRange(r.URL.Query())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Invalid period format: "+err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	result, err := h.Service.QueryHighestSinceByExchange(exchange, symbol, duration)
+	ctx, cancel := h.queryContext(r, "highest")
+	defer cancel()
+	result, err := h.Service.GetHighestInRangeByExchange(ctx, exchange, symbol, rng)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("QueryHighestSinceByExchange success", "exchange", exchange, "symbol", symbol, "period", duration)
+	slog.DebugContext(r.Context(), "Responded with highest price in range by exchange", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(result)
+	_ = json.NewEncoder(w).Encode(result)
 }
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"marketflow/internal/domain"
+)
+
+func (h *Handler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleHistory called", "exchange", exchange, "symbol", symbol)
+
+	query := r.URL.Query()
+
+	rng, err := parseTimeRange(query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, r, domain.NewInvalidArgument("invalid_parameter", "Invalid 'limit', expected a positive integer").WithDetail("parameter", "limit"))
+			return
+		}
+	}
+
+	ctx, cancel := h.queryContext(r, "history")
+	defer cancel()
+	page, err := h.Service.GetHistory(ctx, exchange, symbol, rng, limit, query.Get("cursor"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	slog.DebugContext(r.Context(), "HandleHistory success", "exchange", exchange, "symbol", symbol, "items", len(page.Items))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"marketflow/internal/app/importer"
+	"marketflow/internal/domain"
+)
+
+// ImportLine is one line of the NDJSON import response: progress while the
+// import runs, then either the result or the error that stopped it.
+type ImportLine struct {
+	Progress *importer.Progress `json:"progress,omitempty"`
+	Result   *importer.Result   `json:"result,omitempty"`
+	Error    *ErrorResponse     `json:"error,omitempty"`
+}
+
+// HandleImport ingests the request body as aggregates or raw ticks
+// (?kind=aggregates|ticks, ?format=csv|ndjson) and streams its progress as
+// NDJSON. The body may be gzip-compressed (Content-Encoding: gzip).
+func (h *Handler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	if h.Importer == nil || h.AdminToken == "" {
+		writeError(w, r, domain.NewNotFound("admin_disabled", "admin endpoints are disabled; set admin.token to enable them"))
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.AdminToken)) != 1 {
+		writeError(w, r, &domain.Error{Kind: errUnauthorized, Code: "unauthorized", Message: "a valid admin bearer token is required"})
+		return
+	}
+
+	query := r.URL.Query()
+	opts := importer.Options{Format: query.Get("format"), Kind: query.Get("kind")}
+	if opts.Format == "" {
+		opts.Format = importer.FormatCSV
+	}
+	if opts.Kind == "" {
+		opts.Kind = importer.KindAggregates
+	}
+	slog.InfoContext(r.Context(), "Import started", "format", opts.Format, "kind", opts.Kind)
+
+	var body io.Reader = r.Body
+	if headerContains(r.Header, "Content-Encoding", "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeError(w, r, domain.NewInvalidArgument("invalid_body", "request body is not valid gzip"))
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	// As with exports, the response starts with the first progress line so
+	// a bad request still gets a plain JSON error.
+	var enc *json.Encoder
+	begin := func() {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		enc = json.NewEncoder(w)
+	}
+	rc := http.NewResponseController(w)
+
+	ctx, cancel := h.queryContext(r, "import")
+	defer cancel()
+	res, err := h.Importer.Import(ctx, body, opts, func(p importer.Progress) {
+		if enc == nil {
+			begin()
+		}
+		enc.Encode(ImportLine{Progress: &p})
+		_ = rc.Flush()
+	})
+	if err != nil && enc == nil {
+		writeError(w, r, err)
+		return
+	}
+	if enc == nil {
+		begin()
+	}
+	if err != nil {
+		_, resp := errorResponse(r, err)
+		slog.ErrorContext(r.Context(), "Import failed", "rows", res.Rows, "saved", res.Saved, "err", err)
+		enc.Encode(ImportLine{Error: &resp})
+		return
+	}
+	enc.Encode(ImportLine{Result: &res})
+}
@@ -4,37 +4,90 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strings"
 )
 
 func (h *Handler) HandleLatestPrice(w http.ResponseWriter, r *http.Request) {
-	symbol := strings.TrimPrefix(r.URL.Path, "/prices/latest/")
-	slog.Info("HandleLatestPrice called", "symbol", symbol)
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLatestPrice called", "symbol", symbol)
 
-	data, err := h.Service.GetAggregatedPriceForSymbol(symbol)
+	ctx, cancel := h.queryContext(r, "latest")
+	defer cancel()
+	data, err := h.Service.GetAggregatedPriceForSymbol(ctx, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("HandleLatestPrice success", "symbol", symbol)
+	slog.DebugContext(r.Context(), "HandleLatestPrice success", "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(data)
 }
 
 func (h *Handler) HandleLatestByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/latest/")
-	slog.Info("HandleLatestByExchange called", "path", path)
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLatestByExchange called", "exchange", exchange, "symbol", symbol)
 
-	data, err := h.Service.GetAggregatedPriceForExchange(path)
+	ctx, cancel := h.queryContext(r, "latest")
+	defer cancel()
+	data, err := h.Service.GetAggregatedPriceForExchange(ctx, exchange, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("HandleLatestPrice success", "path", path)
+	slog.DebugContext(r.Context(), "HandleLatestPrice success", "exchange", exchange, "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(data)
 }
+
+func (h *Handler) HandleLatestInRange(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLatestInRange called", "symbol", symbol)
+
+	rng, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ctx, cancel := h.queryContext(r, "latest")
+	defer cancel()
+	result, err := h.Service.GetLatestInRange(ctx, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	slog.DebugContext(r.Context(), "Responded with latest price in range", "symbol", symbol, "from", rng.From, "to", rng.To)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handler) HandleLatestInRangeByExchange(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLatestInRangeByExchange called", "exchange", exchange, "symbol", symbol)
+
+	rng, err := parseTimeRange(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ctx, cancel := h.queryContext(r, "latest")
+	defer cancel()
+	result, err := h.Service.GetLatestInRangeByExchange(ctx, exchange, symbol, rng)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	slog.DebugContext(r.Context(), "Responded with latest price in range by exchange", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -4,108 +4,89 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strings"
-	"time"
 )
 
 func (h *Handler) HandleLowestPrice(w http.ResponseWriter, r *http.Request) {
-	symbol := strings.TrimPrefix(r.URL.Path, "/prices/lowest/")
-	slog.Info("HandleLowestPrice called", "symbol", symbol)
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLowestPrice called", "symbol", symbol)
 
-	data, err := h.Service.GetLowestBySymbol(symbol)
+	ctx, cancel := h.queryContext(r, "lowest")
+	defer cancel()
+	data, err := h.Service.GetLowestBySymbol(ctx, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("GetLowestBySymbol success", "symbol", symbol)
+	slog.DebugContext(r.Context(), "GetLowestBySymbol success", "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(data)
 }
 
 func (h *Handler) HandleLowestByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/lowest/")
-	slog.Info("HandleLowestByExchange called", "path", path)
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLowestByExchange called", "exchange", exchange, "symbol", symbol)
 
-	data, err := h.Service.GetLowestByExchange(path)
+	ctx, cancel := h.queryContext(r, "lowest")
+	defer cancel()
+	data, err := h.Service.GetLowestByExchange(ctx, exchange, symbol)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("GetLowestByExchange success", "path", path)
+	slog.DebugContext(r.Context(), "GetLowestByExchange success", "exchange", exchange, "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(data)
 }
 
-func (h *Handler) HandleLowestByPeriod(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/lowest/")
-	slog.Info("HandleLowestByPeriod called", "path", path)
+func (h *Handler) HandleLowestInRange(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLowestInRange called", "symbol", symbol)
 
-	parts := strings.Split(path, "/")
-	if len(parts) < 1 {
-		writeJSONError(w, http.StatusBadRequest, "Missing symbol")
-		return
-	}
-	symbol := parts[0]
-
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing 'period' query parameter")
-		return
-	}
-
-	duration, err := time.ParseDuration(periodStr)
+	rng, err := parseTimeRange(r.URL.Query())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Invalid period format: "+err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	result, err := h.Service.GetLowestByPeriod(symbol, duration)
+	ctx, cancel := h.queryContext(r, "lowest")
+	defer cancel()
+	result, err := h.Service.GetLowestInRange(ctx, symbol, rng)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("GetLowestByPeriod success", "symbol", symbol, "duration", duration)
+	slog.DebugContext(r.Context(), "Responded with lowest price in range", "symbol", symbol, "from", rng.From, "to", rng.To)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(result)
 }
 
-func (h *Handler) HandleLowestByPeriodByExchange(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/prices/lowest/")
-	slog.Info("HandleLowestByPeriodByExchange called", "path", path)
-
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
-		writeJSONError(w, http.StatusBadRequest, "Missing exchange or symbol")
-		return
-	}
-	exchange := parts[0]
-	symbol := parts[1]
-
-	periodStr := r.URL.Query().Get("period")
-	if periodStr == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing 'period' query parameter")
-		return
-	}
+func (h *Handler) HandleLowestInRangeByExchange(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	slog.DebugContext(r.Context(), "HandleLowestInRangeByExchange called", "exchange", exchange, "symbol", symbol)
 
-	duration, err := time.ParseDuration(periodStr)
+	rng, err := parseTimeRange(r.URL.Query())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Invalid period format: "+err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	result, err := h.Service.QueryLowestSinceByExchange(exchange, symbol, duration)
+	ctx, cancel := h.queryContext(r, "lowest")
+	defer cancel()
+	result, err := h.Service.GetLowestInRangeByExchange(ctx, exchange, symbol, rng)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("QueryLowestSinceByExchange success", "exchange", exchange, "symbol", symbol, "duration", duration)
+	slog.DebugContext(r.Context(), "Responded with lowest price in range by exchange", "exchange", exchange, "symbol", symbol, "from", rng.From, "to", rng.To)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(result)
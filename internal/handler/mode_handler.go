@@ -12,31 +12,43 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 
+type ModeResponse struct {
+	Mode string `json:"mode"`
+}
+
+func (h *Handler) GetMode(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "GetMode called")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ModeResponse{Mode: h.ModeManager.GetMode().String()})
+}
+
 func (h *Handler) SwitchToTestMode(w http.ResponseWriter, r *http.Request) {
-	slog.Info("SwitchToTestMode called")
+	slog.DebugContext(r.Context(), "SwitchToTestMode called")
 
 	err := h.ModeManager.SetMode(r.Context(), mode.ModeTest)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("Mode switched to TEST")
+	slog.DebugContext(r.Context(), "Mode switched to TEST")
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(MessageResponse{Message: "Switched to Test Mode"})
 }
 
 func (h *Handler) SwitchToLiveMode(w http.ResponseWriter, r *http.Request) {
-	slog.Info("SwitchToLiveMode called")
+	slog.DebugContext(r.Context(), "SwitchToLiveMode called")
 
 	err := h.ModeManager.SetMode(r.Context(), mode.ModeLive)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, r, err)
 		return
 	}
 
-	slog.Info("Mode switched to LIVE")
+	slog.DebugContext(r.Context(), "Mode switched to LIVE")
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(MessageResponse{Message: "Switched to Live Mode"})
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/domain"
+	"marketflow/internal/metrics"
+)
+
+// APIVersionPrefix is the prefix of the versioned routes. Every route is also
+// served at its unversioned path for existing clients.
+const APIVersionPrefix = "/v1"
+
+// Router dispatches requests with Go 1.22 ServeMux patterns and reports
+// unmatched paths (404) and methods (405) in the JSON error format.
+type Router struct {
+	mux *http.ServeMux
+}
+
+func NewRouter(h *Handler, health http.Handler) *Router {
+	mux := http.NewServeMux()
+
+	for _, prefix := range []string{APIVersionPrefix, ""} {
+		mux.HandleFunc("GET "+prefix+"/prices/latest/{symbol}", h.Latest)
+		mux.HandleFunc("GET "+prefix+"/prices/latest/{exchange}/{symbol}", h.Latest)
+		mux.HandleFunc("GET "+prefix+"/prices/highest/{symbol}", h.Highest)
+		mux.HandleFunc("GET "+prefix+"/prices/highest/{exchange}/{symbol}", h.Highest)
+		mux.HandleFunc("GET "+prefix+"/prices/lowest/{symbol}", h.Lowest)
+		mux.HandleFunc("GET "+prefix+"/prices/lowest/{exchange}/{symbol}", h.Lowest)
+		mux.HandleFunc("GET "+prefix+"/prices/average/{symbol}", h.Average)
+		mux.HandleFunc("GET "+prefix+"/prices/average/{exchange}/{symbol}", h.Average)
+		mux.HandleFunc("GET "+prefix+"/prices/history/{symbol}", h.HandleHistory)
+		mux.HandleFunc("GET "+prefix+"/prices/history/{exchange}/{symbol}", h.HandleHistory)
+
+		mux.HandleFunc("POST "+prefix+"/prices/query", h.HandleBatchQuery)
+
+		mux.HandleFunc("GET "+prefix+"/stream/prices", h.HandleStreamPrices)
+		mux.HandleFunc("GET "+prefix+"/ws", h.HandleWebSocket)
+
+		mux.HandleFunc("GET "+prefix+"/exchanges", h.HandleExchanges)
+		mux.HandleFunc("GET "+prefix+"/symbols", h.HandleSymbols)
+		mux.HandleFunc("GET "+prefix+"/coverage", h.HandleCoverage)
+		mux.HandleFunc("GET "+prefix+"/export/aggregates", h.HandleExport)
+
+		mux.HandleFunc("POST "+prefix+"/admin/import", h.HandleImport)
+
+		mux.HandleFunc("GET "+prefix+"/mode", h.GetMode)
+		mux.HandleFunc("POST "+prefix+"/mode/test", h.SwitchToTestMode)
+		mux.HandleFunc("POST "+prefix+"/mode/live", h.SwitchToLiveMode)
+
+		mux.Handle("GET "+prefix+"/health", health)
+		mux.Handle("GET "+prefix+"/metrics", metrics.Handler())
+	}
+
+	return &Router{mux: mux}
+}
+
+// ServeHTTP routes the request and records its status and latency under the
+// matched route pattern.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w}
+
+	_, pattern := rt.mux.Handler(r)
+	route := routeLabel(pattern)
+	defer func() {
+		metrics.HTTPRequests.Inc(route, r.Method, strconv.Itoa(rec.Status()))
+		metrics.HTTPRequestDuration.ObserveSince(start, route, r.Method)
+	}()
+
+	if pattern != "" {
+		rt.mux.ServeHTTP(rec, r)
+		return
+	}
+	rt.serveUnmatched(rec, r)
+}
+
+// routeLabel strips the method from a ServeMux pattern; unmatched requests
+// share one label so arbitrary paths cannot grow the metric set.
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		return pattern[i+1:]
+	}
+	return pattern
+}
+
+func (rt *Router) serveUnmatched(w http.ResponseWriter, r *http.Request) {
+	// No route matched. Let the mux decide between 404, 405 and a path
+	// cleaning redirect, then replace its plain-text body with a JSON one.
+	ew := &unmatchedWriter{ResponseWriter: w}
+	rt.mux.ServeHTTP(ew, r)
+
+	switch ew.status {
+	case http.StatusMethodNotAllowed:
+		writeError(w, r, &domain.Error{
+			Kind:    errMethodNotAllowed,
+			Code:    "method_not_allowed",
+			Message: "method " + r.Method + " is not allowed for " + r.URL.Path,
+			Details: map[string]interface{}{"allow": w.Header().Get("Allow")},
+		})
+	case http.StatusNotFound:
+		writeError(w, r, domain.NewNotFound("route_not_found", "no route for "+r.Method+" "+r.URL.Path))
+	}
+}
+
+// unmatchedWriter swallows the mux's plain-text 404/405 responses and passes
+// anything else, such as redirects, through.
+type unmatchedWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *unmatchedWriter) WriteHeader(status int) {
+	w.status = status
+	if status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.status == http.StatusNotFound || w.status == http.StatusMethodNotAllowed {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"marketflow/internal/app/stream"
+	"marketflow/internal/domain"
+)
+
+const sseHeartbeatInterval = 15 * time.Second
+
+// HandleStreamPrices serves live ticks as server-sent events, plus each
+// minute's aggregates with ?aggregates=true and arbitrage alerts with
+// ?arbitrage=true. ?symbols= and ?exchanges= take comma-separated lists;
+// omitted lists match everything.
+func (h *Handler) HandleStreamPrices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	slog.DebugContext(r.Context(), "HandleStreamPrices called", "symbols", query.Get("symbols"), "exchanges", query.Get("exchanges"))
+
+	filter, err := h.streamFilter(query.Get("symbols"), query.Get("exchanges"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter.Ticks = true
+	for name, dst := range map[string]*bool{"aggregates": &filter.Aggregates, "arbitrage": &filter.Arbitrage} {
+		if v := query.Get(name); v != "" {
+			if *dst, err = strconv.ParseBool(v); err != nil {
+				writeError(w, r, domain.NewInvalidArgument("invalid_parameter", "Invalid '"+name+"', expected a boolean").WithDetail("parameter", name))
+				return
+			}
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Streaming not supported by response writer", "err", err)
+		return
+	}
+
+	sub := h.Stream.Subscribe(filter, stream.DefaultBuffer)
+	defer h.Stream.Unsubscribe(sub)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			slog.DebugContext(r.Context(), "Stream client disconnected")
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					fmt.Fprint(w, "event: error\ndata: {\"code\":\"slow_consumer\",\"error\":\"client could not keep up with the stream\"}\n\n")
+					_ = rc.Flush()
+				}
+				return
+			}
+			if err := writeSSEEvent(w, e); err != nil {
+				slog.WarnContext(r.Context(), "Failed to write stream event", "err", err)
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			slog.WarnContext(r.Context(), "Failed to flush stream", "err", err)
+			return
+		}
+	}
+}
+
+func (h *Handler) streamFilter(symbols, exchanges string) (stream.Filter, error) {
+	filter := stream.Filter{Symbols: map[string]bool{}, Exchanges: map[string]bool{}}
+
+	resolved, err := h.Service.ResolveSymbols(splitList(symbols))
+	if err != nil {
+		return stream.Filter{}, err
+	}
+	for _, sym := range resolved {
+		filter.Symbols[sym] = true
+	}
+
+	resolved, err = h.Service.ResolveExchanges(splitList(exchanges))
+	if err != nil {
+		return stream.Filter{}, err
+	}
+	for _, ex := range resolved {
+		filter.Exchanges[ex] = true
+	}
+	return filter, nil
+}
+
+func writeSSEEvent(w http.ResponseWriter, e stream.Event) error {
+	data, err := json.Marshal(eventPayload(e))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func eventPayload(e stream.Event) interface{} {
+	switch e.Type {
+	case stream.EventAggregate:
+		return e.Aggregate
+	case stream.EventArbitrage:
+		return e.Arbitrage
+	}
+	return e.Tick
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"net/url"
+	"time"
+
+	"marketflow/internal/domain"
+)
+
+func hasTimeRange(q url.Values) bool {
+	return q.Has("period") || q.Has("from") || q.Has("to")
+}
+
+// parseTimeRange builds a query range from ?period=, ?from= and ?to=.
+func parseTimeRange(q url.Values) (domain.TimeRange, error) {
+	if q.Has("period") && q.Get("period") == "" {
+		return domain.TimeRange{}, domain.NewInvalidArgument("invalid_time_range", "'period' must not be empty").WithDetail("parameter", "period")
+	}
+	return domain.ResolveTimeRange(q.Get("period"), q.Get("from"), q.Get("to"), time.Now())
+}
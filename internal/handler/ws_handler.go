@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"marketflow/internal/app/stream"
+	"marketflow/internal/domain"
+)
+
+const (
+	WSChannelTicks      = "ticks"
+	WSChannelAggregates = "aggregates"
+	WSChannelArbitrage  = "arbitrage"
+
+	wsBuffer       = 1024
+	wsPingInterval = 30 * time.Second
+)
+
+// wsClientMessage is a control message sent by a WebSocket client. Symbol and
+// exchange are optional; an omitted value subscribes to every series.
+type wsClientMessage struct {
+	Type     string `json:"type"`
+	Channel  string `json:"channel"`
+	Symbol   string `json:"symbol,omitempty"`
+	Exchange string `json:"exchange,omitempty"`
+}
+
+type wsServerMessage struct {
+	Type     string         `json:"type"`
+	Channel  string         `json:"channel,omitempty"`
+	Symbol   string         `json:"symbol,omitempty"`
+	Exchange string         `json:"exchange,omitempty"`
+	Data     interface{}    `json:"data,omitempty"`
+	Error    *ErrorResponse `json:"error,omitempty"`
+}
+
+// wsSubscription is one subscribe request, identified by channel, symbol and
+// exchange so that a matching unsubscribe removes it again.
+type wsSubscription struct {
+	channel  string
+	symbol   string
+	exchange string
+}
+
+// HandleWebSocket upgrades the connection and pushes live events for the
+// channels the client subscribes to. Every subscribe is answered with a
+// "subscribed" message and a snapshot of the latest event per series, after
+// which matching events arrive as "update" messages.
+func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	slog.DebugContext(r.Context(), "HandleWebSocket called", "remote", r.RemoteAddr)
+
+	conn, err := upgradeWebSocket(w, r, h.AllowedOrigins)
+	if err != nil {
+		slog.WarnContext(r.Context(), "WebSocket upgrade failed", "err", err)
+		return
+	}
+
+	// The connection receives every event and filters per subscription here,
+	// so subscribing and unsubscribing never touches the broadcaster.
+	sub := h.Stream.Subscribe(stream.Filter{Ticks: true, Aggregates: true, Arbitrage: true}, wsBuffer)
+	defer h.Stream.Unsubscribe(sub)
+
+	requests := make(chan wsClientMessage)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			data, err := conn.ReadMessage()
+			if err != nil {
+				if !errors.Is(err, errWSClosed) && !errors.Is(err, io.EOF) {
+					slog.WarnContext(r.Context(), "WebSocket read failed", "err", err)
+				}
+				return
+			}
+			var msg wsClientMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				msg = wsClientMessage{Type: "invalid"}
+			}
+			select {
+			case requests <- msg:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+
+	subs := make(map[wsSubscription]stream.Filter)
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-done:
+			slog.DebugContext(r.Context(), "WebSocket client disconnected")
+			conn.Close(wsCloseNormal, "")
+			return
+		case <-ping.C:
+			err = conn.writeFrame(wsOpPing, nil)
+		case msg := <-requests:
+			err = h.handleWSMessage(conn, r, subs, msg)
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					conn.Close(wsClosePolicy, "slow consumer")
+				} else {
+					conn.Close(wsCloseNormal, "")
+				}
+				return
+			}
+			if wsMatch(subs, e) {
+				err = writeWSMessage(conn, wsServerMessage{Type: "update", Channel: wsChannel(e.Type), Data: eventPayload(e)})
+			}
+		}
+		if err != nil {
+			slog.WarnContext(r.Context(), "WebSocket write failed", "err", err)
+			conn.Close(wsCloseNormal, "")
+			return
+		}
+	}
+}
+
+func (h *Handler) handleWSMessage(conn *wsConn, r *http.Request, subs map[wsSubscription]stream.Filter, msg wsClientMessage) error {
+	switch msg.Type {
+	case "ping":
+		return writeWSMessage(conn, wsServerMessage{Type: "pong"})
+	case "subscribe", "unsubscribe":
+	default:
+		return writeWSError(conn, r, domain.NewInvalidArgument("invalid_message", "message type must be one of subscribe, unsubscribe, ping").
+			WithDetail("type", msg.Type))
+	}
+
+	if strings.Contains(msg.Symbol, ",") || strings.Contains(msg.Exchange, ",") {
+		return writeWSError(conn, r, domain.NewInvalidArgument("invalid_message", "subscribe to one symbol and exchange per message"))
+	}
+	filter, err := h.streamFilter(msg.Symbol, msg.Exchange)
+	if err != nil {
+		return writeWSError(conn, r, err)
+	}
+	switch msg.Channel {
+	case WSChannelTicks:
+		filter.Ticks = true
+	case WSChannelAggregates:
+		filter.Aggregates = true
+	case WSChannelArbitrage:
+		filter.Arbitrage = true
+	default:
+		return writeWSError(conn, r, domain.NewInvalidArgument("invalid_channel", "unknown channel").
+			WithDetail("channel", msg.Channel).
+			WithDetail("valid_channels", []string{WSChannelTicks, WSChannelAggregates, WSChannelArbitrage}))
+	}
+
+	key := wsSubscription{channel: msg.Channel}
+	for sym := range filter.Symbols {
+		key.symbol = sym
+	}
+	for ex := range filter.Exchanges {
+		key.exchange = ex
+	}
+	reply := wsServerMessage{Type: msg.Type + "d", Channel: key.channel, Symbol: key.symbol, Exchange: key.exchange}
+
+	if msg.Type == "unsubscribe" {
+		delete(subs, key)
+		return writeWSMessage(conn, reply)
+	}
+
+	subs[key] = filter
+	if err := writeWSMessage(conn, reply); err != nil {
+		return err
+	}
+	snapshot := make([]interface{}, 0)
+	for _, e := range h.Stream.Snapshot(filter) {
+		snapshot = append(snapshot, eventPayload(e))
+	}
+	return writeWSMessage(conn, wsServerMessage{Type: "snapshot", Channel: key.channel, Symbol: key.symbol, Exchange: key.exchange, Data: snapshot})
+}
+
+func wsMatch(subs map[wsSubscription]stream.Filter, e stream.Event) bool {
+	for _, filter := range subs {
+		if filter.Match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func wsChannel(eventType string) string {
+	switch eventType {
+	case stream.EventAggregate:
+		return WSChannelAggregates
+	case stream.EventArbitrage:
+		return WSChannelArbitrage
+	}
+	return WSChannelTicks
+}
+
+func writeWSMessage(conn *wsConn, msg wsServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(data)
+}
+
+func writeWSError(conn *wsConn, r *http.Request, err error) error {
+	_, resp := errorResponse(r, err)
+	slog.WarnContext(r.Context(), "Rejecting WebSocket message", "code", resp.Code, "message", resp.Error)
+	return writeWSMessage(conn, wsServerMessage{Type: "error", Error: &resp})
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimal server side of RFC 6455: the opening handshake, unfragmented and
+// fragmented text messages, ping/pong and the close handshake. Extensions and
+// subprotocols are not negotiated.
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseUnsupported   = 1003
+	wsCloseTooLarge      = 1009
+	wsClosePolicy        = 1008
+
+	wsMaxMessageSize = 64 << 10
+	wsWriteTimeout   = 10 * time.Second
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+var errWSClosed = errors.New("websocket closed")
+
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	wmu  sync.Mutex
+}
+
+// upgradeWebSocket validates the handshake request and hijacks the
+// connection. On failure the error has already been written to w.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*wsConn, error) {
+	if !checkOrigin(r, allowedOrigins) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, errors.New("origin not allowed: " + r.Header.Get("Origin"))
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected websocket upgrade", http.StatusUpgradeRequired)
+		return nil, errors.New("missing upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing websocket key")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// checkOrigin guards against cross-site WebSocket hijacking: browsers always
+// send Origin, so a request from another site must be listed in allowed.
+// Requests without Origin come from non-browser clients and are accepted.
+func checkOrigin(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage returns the next text message, answering pings and the close
+// handshake transparently. It returns errWSClosed once the peer has closed.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	inMessage := false
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+		case wsOpPong:
+		case wsOpClose:
+			code := wsCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			_ = c.Close(code, "")
+			return nil, errWSClosed
+		case wsOpText, wsOpBinary:
+			if inMessage {
+				_ = c.Close(wsCloseProtocolError, "expected continuation frame")
+				return nil, errors.New("unexpected data frame")
+			}
+			if op == wsOpBinary {
+				_ = c.Close(wsCloseUnsupported, "binary messages are not supported")
+				return nil, errors.New("binary message")
+			}
+			msg, inMessage = payload, true
+		case wsOpContinuation:
+			if !inMessage {
+				_ = c.Close(wsCloseProtocolError, "unexpected continuation frame")
+				return nil, errors.New("unexpected continuation frame")
+			}
+			msg = append(msg, payload...)
+		default:
+			_ = c.Close(wsCloseProtocolError, "unknown opcode")
+			return nil, errors.New("unknown opcode")
+		}
+
+		if len(msg) > wsMaxMessageSize {
+			_ = c.Close(wsCloseTooLarge, "message too large")
+			return nil, errors.New("message too large")
+		}
+		// Control frames may arrive between the fragments of a message, so
+		// only a final data frame completes it.
+		if inMessage && fin && op < wsOpClose {
+			return msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.rw, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0F
+	if head[0]&0x70 != 0 {
+		_ = c.Close(wsCloseProtocolError, "reserved bits set")
+		return false, 0, nil, errors.New("reserved bits set")
+	}
+	if head[1]&0x80 == 0 {
+		_ = c.Close(wsCloseProtocolError, "client frames must be masked")
+		return false, 0, nil, errors.New("unmasked client frame")
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= wsOpClose && (length > 125 || !fin) {
+		_ = c.Close(wsCloseProtocolError, "invalid control frame")
+		return false, 0, nil, errors.New("invalid control frame")
+	}
+	if length > wsMaxMessageSize {
+		_ = c.Close(wsCloseTooLarge, "message too large")
+		return false, 0, nil, errors.New("frame too large")
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.rw, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	head := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n <= 125:
+		head = append(head, byte(n))
+	case n <= 0xFFFF:
+		head = append(head, 126, byte(n>>8), byte(n))
+	default:
+		head = append(head, 127)
+		head = binary.BigEndian.AppendUint64(head, uint64(n))
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.rw.Write(head); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// Close sends a close frame with the given status code and closes the
+// underlying connection.
+func (c *wsConn) Close(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	_ = c.writeFrame(wsOpClose, append(payload, reason...))
+	return c.conn.Close()
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newEchoServer upgrades every request and echoes text messages back until
+// the connection closes.
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgradeWebSocket(w, r, []string{"https://dashboard.example.com"})
+		if err != nil {
+			return
+		}
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteText(msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type wsClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialWS performs the opening handshake with the given extra headers and
+// returns the response along with the client, which is nil unless the
+// server switched protocols.
+func dialWS(t *testing.T, srv *httptest.Server, header http.Header) (*http.Response, *wsClient) {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+	return resp, &wsClient{t: t, conn: conn, r: r}
+}
+
+// send writes one client frame, masked unless unmasked is set.
+func (c *wsClient) send(fin bool, op byte, payload []byte, unmasked bool) {
+	c.t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	maskBit := byte(0x80)
+	if unmasked {
+		maskBit = 0
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if unmasked {
+		frame = append(frame, payload...)
+	} else {
+		mask := []byte{0x37, 0xfa, 0x21, 0x3d}
+		frame = append(frame, mask...)
+		for i, b := range payload {
+			frame = append(frame, b^mask[i%4])
+		}
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// recv reads one server frame, which must be final and unmasked.
+func (c *wsClient) recv() (byte, []byte) {
+	c.t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		c.t.Fatalf("read frame: %v", err)
+	}
+	if head[0]&0x80 == 0 || head[1]&0x80 != 0 {
+		c.t.Fatalf("frame header %x: want FIN set and no mask", head)
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.r, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		c.t.Fatalf("read payload: %v", err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+// expectClose reads a close frame with the given status code and checks the
+// server then drops the connection.
+func (c *wsClient) expectClose(code int) {
+	c.t.Helper()
+	op, payload := c.recv()
+	if op != wsOpClose || len(payload) < 2 {
+		c.t.Fatalf("got opcode %#x %q, want close", op, payload)
+	}
+	if got := int(binary.BigEndian.Uint16(payload)); got != code {
+		c.t.Errorf("close code = %d (%s), want %d", got, payload[2:], code)
+	}
+	if _, err := c.r.ReadByte(); err != io.EOF {
+		c.t.Errorf("read after close = %v, want EOF", err)
+	}
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	srv := newEchoServer(t)
+	resp, c := dialWS(t, srv, nil)
+	if c == nil {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	// The accept value for this key is the worked example of RFC 6455 §1.3.
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Sec-WebSocket-Accept = %q", got)
+	}
+}
+
+func TestWebSocketHandshakeRejections(t *testing.T) {
+	srv := newEchoServer(t)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"wrong version", http.Header{"Sec-Websocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"missing key", http.Header{"Sec-Websocket-Key": {""}}, http.StatusBadRequest},
+		{"not an upgrade", http.Header{"Upgrade": {"h2c"}}, http.StatusUpgradeRequired},
+		{"cross-site origin", http.Header{"Origin": {"https://evil.example.com"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, c := dialWS(t, srv, tt.header)
+			if c != nil || resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	allowed := []string{"https://dashboard.example.com/"}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://api.example.com:8080", true},
+		{"http://API.example.com:8080", true},
+		{"https://dashboard.example.com", true},
+		{"https://dashboard.example.com:8443", false},
+		{"http://api.example.com", false},
+		{"https://evil.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com:8080/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := checkOrigin(r, allowed); got != tt.want {
+			t.Errorf("checkOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/ws", nil)
+	r.Header.Set("Origin", "https://anywhere.example.org")
+	if !checkOrigin(r, []string{"*"}) {
+		t.Error("checkOrigin with \"*\" rejected an origin")
+	}
+}
+
+func TestWebSocketFrames(t *testing.T) {
+	srv := newEchoServer(t)
+	_, c := dialWS(t, srv, http.Header{"Origin": {"https://dashboard.example.com"}})
+	if c == nil {
+		t.Fatal("handshake failed")
+	}
+
+	c.send(true, wsOpText, []byte("hello"), false)
+	if op, payload := c.recv(); op != wsOpText || string(payload) != "hello" {
+		t.Errorf("echo = %#x %q, want text \"hello\"", op, payload)
+	}
+
+	// A fragmented message with a ping between its frames: the ping is
+	// answered at once and the fragments are joined.
+	c.send(false, wsOpText, []byte("frag"), false)
+	c.send(true, wsOpPing, []byte("p"), false)
+	c.send(true, wsOpContinuation, []byte("mented"), false)
+	if op, payload := c.recv(); op != wsOpPong || string(payload) != "p" {
+		t.Errorf("got %#x %q, want pong \"p\"", op, payload)
+	}
+	if op, payload := c.recv(); op != wsOpText || string(payload) != "fragmented" {
+		t.Errorf("got %#x %q, want text \"fragmented\"", op, payload)
+	}
+
+	// 16-bit extended payload lengths in both directions; a 64-bit length
+	// would exceed wsMaxMessageSize and is covered by the oversized cases.
+	for _, n := range []int{126, 300, 0xFFFF} {
+		msg := bytes.Repeat([]byte("x"), n)
+		c.send(true, wsOpText, msg, false)
+		if op, payload := c.recv(); op != wsOpText || !bytes.Equal(payload, msg) {
+			t.Errorf("%d-byte echo: got %#x with %d bytes", n, op, len(payload))
+		}
+	}
+
+	closing := binary.BigEndian.AppendUint16(nil, 1001)
+	c.send(true, wsOpClose, closing, false)
+	c.expectClose(1001)
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(c *wsClient)
+		code int
+	}{
+		{"unmasked frame", func(c *wsClient) { c.send(true, wsOpText, []byte("hi"), true) }, wsCloseProtocolError},
+		{"reserved bits", func(c *wsClient) { c.send(true, 0x40|wsOpText, []byte("hi"), false) }, wsCloseProtocolError},
+		{"binary message", func(c *wsClient) { c.send(true, wsOpBinary, []byte{1}, false) }, wsCloseUnsupported},
+		{"stray continuation", func(c *wsClient) { c.send(true, wsOpContinuation, []byte("x"), false) }, wsCloseProtocolError},
+		{"unknown opcode", func(c *wsClient) { c.send(true, 0x3, nil, false) }, wsCloseProtocolError},
+		{"fragmented ping", func(c *wsClient) { c.send(false, wsOpPing, nil, false) }, wsCloseProtocolError},
+		{"new message mid-fragment", func(c *wsClient) {
+			c.send(false, wsOpText, []byte("a"), false)
+			c.send(true, wsOpText, []byte("b"), false)
+		}, wsCloseProtocolError},
+		{"oversized frame", func(c *wsClient) {
+			// Only the header is sent; the length alone must be refused.
+			head := []byte{0x80 | wsOpText, 0x80 | 127}
+			c.conn.Write(binary.BigEndian.AppendUint64(head, wsMaxMessageSize+1))
+		}, wsCloseTooLarge},
+		{"oversized message", func(c *wsClient) {
+			half := bytes.Repeat([]byte("x"), wsMaxMessageSize/2+1)
+			c.send(false, wsOpText, half, false)
+			c.send(true, wsOpContinuation, half, false)
+		}, wsCloseTooLarge},
+	}
+	srv := newEchoServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, c := dialWS(t, srv, nil)
+			if c == nil {
+				t.Fatal("handshake failed")
+			}
+			tt.send(c)
+			c.expectClose(tt.code)
+		})
+	}
+}
+
+func TestHeaderContains(t *testing.T) {
+	h := http.Header{"Connection": {"keep-alive, Upgrade"}}
+	if !headerContains(h, "Connection", "upgrade") {
+		t.Error("token in a comma-separated list not found")
+	}
+	if headerContains(h, "Connection", "close") || headerContains(h, "Upgrade", "websocket") {
+		t.Error("absent token found")
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http"
+)
+
+// Default is the registry served at /metrics.
+var Default = NewRegistry()
+
+// Ingest pipeline.
+var (
+	TicksReceived = Default.NewCounter("marketflow_ticks_received_total",
+		"Ticks received from sources.", "exchange", "symbol")
+	TicksDropped = Default.NewCounter("marketflow_ticks_dropped_total",
+		"Ticks dropped by a source's backpressure policy.", "exchange", "symbol")
+	TicksCoalesced = Default.NewCounter("marketflow_ticks_coalesced_total",
+		"Ticks replaced by a newer tick of the same symbol before delivery.", "exchange", "symbol")
+	ParseErrors = Default.NewCounter("marketflow_parse_errors_total",
+		"Source messages that could not be parsed.", "exchange")
+	SourceReconnects = Default.NewCounter("marketflow_source_reconnects_total",
+		"Connection attempts to exchange sources after the first.", "exchange")
+
+	FanoutDelivered = Default.NewCounter("marketflow_fanout_delivered_total",
+		"Ticks delivered to a fan-out subscriber's queue.", "subscriber")
+	FanoutDropped = Default.NewCounter("marketflow_fanout_dropped_total",
+		"Ticks dropped because a fan-out subscriber's queue was full.", "subscriber")
+	FanoutQueued = Default.NewGauge("marketflow_fanout_queue_length",
+		"Ticks waiting in a fan-out subscriber's queue.", "subscriber")
+)
+
+// Storage.
+var (
+	RedisOpDuration = Default.NewHistogram("marketflow_redis_op_duration_seconds",
+		"Latency of Redis commands.", DefBuckets, "op")
+	RedisErrors = Default.NewCounter("marketflow_redis_errors_total",
+		"Failed Redis commands.", "op")
+
+	PostgresQueryDuration = Default.NewHistogram("marketflow_postgres_query_duration_seconds",
+		"Latency of Postgres queries per repository method.", DefBuckets, "method")
+
+	AggregatorCycleDuration = Default.NewHistogram("marketflow_aggregator_cycle_duration_seconds",
+		"Duration of one aggregation cycle over every series.", DefBuckets)
+	AggregatorRowsWritten = Default.NewCounter("marketflow_aggregator_rows_written_total",
+		"Aggregated rows written to Postgres.")
+	RollupRowsWritten = Default.NewCounter("marketflow_rollup_rows_written_total",
+		"Rollup rows written or updated, by resolution.", "resolution")
+	RollupRowsPruned = Default.NewCounter("marketflow_rollup_rows_pruned_total",
+		"Aggregate rows deleted past their retention, by resolution.", "resolution")
+)
+
+// HTTP and runtime state.
+var (
+	HTTPRequests = Default.NewCounter("marketflow_http_requests_total",
+		"HTTP requests by route, method and status code.", "route", "method", "code")
+	HTTPRequestDuration = Default.NewHistogram("marketflow_http_request_duration_seconds",
+		"HTTP request latency by route and method.", DefBuckets, "route", "method")
+
+	Mode = Default.NewGauge("marketflow_mode",
+		"Current data mode; the active mode is 1.", "mode")
+	Leader = Default.NewGauge("marketflow_leader",
+		"Whether this instance holds the aggregator lease (1) or not (0).")
+)
+
+// Handler serves the Default registry in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = Default.WriteText(w)
+	})
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterAndGaugeText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("requests_total", "Requests served.", "route", "code")
+	g := r.NewGauge("queue_length", "Items waiting.")
+
+	c.Inc("/prices", "200")
+	c.Add(2, "/prices", "200")
+	c.Inc("/health", "503")
+	g.Set(7)
+	g.Add(-2)
+
+	if got := c.Value("/prices", "200"); got != 3 {
+		t.Errorf("counter value = %v, want 3", got)
+	}
+	want := `# HELP queue_length Items waiting.
+# TYPE queue_length gauge
+queue_length 5
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/health",code="503"} 1
+requests_total{route="/prices",code="200"} 3
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHistogramBucketsAreCumulative(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1, 0.5}, "op")
+	for _, v := range []float64{0.05, 0.1, 0.3, 2} {
+		h.Observe(v, "read")
+	}
+
+	if got := h.Count("read"); got != 4 {
+		t.Errorf("count = %d, want 4", got)
+	}
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="read",le="0.1"} 2
+latency_seconds_bucket{op="read",le="0.5"} 3
+latency_seconds_bucket{op="read",le="1"} 3
+latency_seconds_bucket{op="read",le="+Inf"} 4
+latency_seconds_sum{op="read"} 2.45
+latency_seconds_count{op="read"} 4
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("errors_total", "Errors by \\ message\nand cause.", "message")
+	c.Inc("say \"hi\"\\\n")
+
+	want := `# HELP errors_total Errors by \\ message\nand cause.
+# TYPE errors_total counter
+errors_total{message="say \"hi\"\\\n"} 1
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCollectorsRunBeforeRender(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGauge("depth", "Depth.")
+	depth := 0
+	r.OnCollect(func() { depth++; g.Set(float64(depth)) })
+
+	render(t, r)
+	if out := render(t, r); !strings.Contains(out, "\ndepth 2\n") {
+		t.Errorf("WriteText after two renders:\n%s\nwant depth 2", out)
+	}
+}
+
+func TestMisuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("things_total", "Things.", "kind")
+
+	mustPanic(t, "duplicate registration", func() { r.NewGauge("things_total", "Again.") })
+	mustPanic(t, "wrong label count", func() { c.Inc() })
+	mustPanic(t, "negative counter delta", func() { c.Add(-1, "a") })
+}
+
+func mustPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: want panic", name)
+		}
+	}()
+	fn()
+}
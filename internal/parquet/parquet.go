@@ -0,0 +1,271 @@
+// Package parquet writes flat tables as Parquet files: required columns of
+// strings, timestamps and doubles, PLAIN-encoded and uncompressed, one data
+// page per column in each row group. That is the subset any Parquet reader
+// accepts and all an export needs.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Type is the type of a column's values.
+type Type int
+
+const (
+	// String columns take string values, stored as UTF-8 byte arrays.
+	String Type = iota
+	// Timestamp columns take time.Time values, stored as UTC milliseconds.
+	Timestamp
+	// Double columns take float64 values.
+	Double
+)
+
+// Column names a column and the type of its values.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// RowGroupRows is the number of rows buffered before a row group is written.
+const RowGroupRows = 1 << 16
+
+const magic = "PAR1"
+
+// Parquet enum values used in the footer and page headers.
+const (
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	repetitionRequired = 0
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	pageData          = 0
+)
+
+// Writer writes rows to a Parquet file. Rows are buffered per row group, so
+// memory stays bounded however many rows are written. Close writes the
+// footer; without it the file is not readable.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	values  [][]byte // PLAIN-encoded values of the open row group, per column
+	rows    int
+	offset  int64
+	groups  []rowGroup
+	err     error
+}
+
+type rowGroup struct {
+	rows   int64
+	size   int64
+	chunks []chunk
+}
+
+type chunk struct {
+	offset, size int64
+	values       int64
+}
+
+// NewWriter starts a Parquet file with the given columns on w.
+func NewWriter(w io.Writer, columns ...Column) *Writer {
+	pw := &Writer{w: w, columns: columns, values: make([][]byte, len(columns))}
+	pw.write([]byte(magic))
+	return pw
+}
+
+// Write appends a row holding one value per column, of the column's type.
+func (w *Writer) Write(row ...any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+	// Check the whole row first so a bad value leaves no partial row behind.
+	for i, v := range row {
+		if err := check(w.columns[i], v); err != nil {
+			return err
+		}
+	}
+	for i, v := range row {
+		b := w.values[i]
+		switch v := v.(type) {
+		case string:
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+			b = append(b, v...)
+		case time.Time:
+			b = binary.LittleEndian.AppendUint64(b, uint64(v.UnixMilli()))
+		case float64:
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+		}
+		w.values[i] = b
+	}
+	w.rows++
+	if w.rows == RowGroupRows {
+		w.flush()
+	}
+	return w.err
+}
+
+func check(c Column, v any) error {
+	var ok bool
+	switch c.Type {
+	case String:
+		_, ok = v.(string)
+	case Timestamp:
+		_, ok = v.(time.Time)
+	case Double:
+		_, ok = v.(float64)
+	}
+	if !ok {
+		return fmt.Errorf("parquet: column %s cannot hold %T", c.Name, v)
+	}
+	return nil
+}
+
+// Close writes the open row group and the footer. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.flush()
+	footer := w.footer()
+	w.write(footer)
+	w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	w.write([]byte(magic))
+	return w.err
+}
+
+// flush writes the buffered rows as a row group of one data page per column.
+func (w *Writer) flush() {
+	if w.rows == 0 {
+		return
+	}
+	g := rowGroup{rows: int64(w.rows)}
+	for i, values := range w.values {
+		var c compact
+		c.beginStruct()
+		c.i32(1, pageData)
+		c.i32(2, int32(len(values)))
+		c.i32(3, int32(len(values)))
+		c.structField(5)
+		c.i32(1, int32(w.rows))
+		c.i32(2, encodingPlain)
+		c.i32(3, encodingRLE)
+		c.i32(4, encodingRLE)
+		c.endStruct()
+		c.endStruct()
+
+		ch := chunk{offset: w.offset, size: int64(len(c.buf) + len(values)), values: int64(w.rows)}
+		w.write(c.buf)
+		w.write(values)
+		g.chunks = append(g.chunks, ch)
+		g.size += ch.size
+		w.values[i] = values[:0]
+	}
+	w.groups = append(w.groups, g)
+	w.rows = 0
+}
+
+// footer encodes the FileMetaData: the schema and where each column chunk is.
+func (w *Writer) footer() []byte {
+	var c compact
+	var rows int64
+	for _, g := range w.groups {
+		rows += g.rows
+	}
+	c.beginStruct()
+	c.i32(1, 1)
+	c.list(2, ctStruct, len(w.columns)+1)
+	c.beginStruct()
+	c.string(4, "schema")
+	c.i32(5, int32(len(w.columns)))
+	c.endStruct()
+	for _, col := range w.columns {
+		c.beginStruct()
+		c.i32(1, physicalType(col.Type))
+		c.i32(3, repetitionRequired)
+		c.string(4, col.Name)
+		switch col.Type {
+		case String:
+			c.i32(6, convertedUTF8)
+			c.structField(10)
+			c.structField(1) // STRING
+			c.endStruct()
+			c.endStruct()
+		case Timestamp:
+			c.i32(6, convertedTimestampMillis)
+			c.structField(10)
+			c.structField(8) // TIMESTAMP
+			c.bool(1, true)
+			c.structField(2)
+			c.structField(1) // MILLIS
+			c.endStruct()
+			c.endStruct()
+			c.endStruct()
+			c.endStruct()
+		}
+		c.endStruct()
+	}
+	c.i64(3, rows)
+	c.list(4, ctStruct, len(w.groups))
+	for _, g := range w.groups {
+		c.beginStruct()
+		c.list(1, ctStruct, len(g.chunks))
+		for i, ch := range g.chunks {
+			col := w.columns[i]
+			c.beginStruct()
+			c.i64(2, ch.offset)
+			c.structField(3)
+			c.i32(1, physicalType(col.Type))
+			c.list(2, ctI32, 1)
+			c.i32Elem(encodingPlain)
+			c.list(3, ctBinary, 1)
+			c.stringElem(col.Name)
+			c.i32(4, codecUncompressed)
+			c.i64(5, ch.values)
+			c.i64(6, ch.size)
+			c.i64(7, ch.size)
+			c.i64(9, ch.offset)
+			c.endStruct()
+			c.endStruct()
+		}
+		c.i64(2, g.size)
+		c.i64(3, g.rows)
+		c.endStruct()
+	}
+	c.string(6, "marketflow")
+	c.endStruct()
+	return c.buf
+}
+
+func physicalType(t Type) int32 {
+	switch t {
+	case Timestamp:
+		return typeInt64
+	case Double:
+		return typeDouble
+	}
+	return typeByteArray
+}
+
+func (w *Writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	w.err = err
+}
@@ -0,0 +1,193 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// thriftStruct is a decoded compact-protocol struct, by field id.
+type thriftStruct map[int16]any
+
+// decoder reads the compact protocol back, independently of the encoder,
+// so the test checks the bytes rather than the encoder against itself.
+type decoder struct {
+	t   *testing.T
+	buf []byte
+}
+
+func (d *decoder) byte() byte {
+	if len(d.buf) == 0 {
+		d.t.Fatal("unexpected end of thrift data")
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.t.Fatal("bad varint")
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) zigzag() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *decoder) value(typ byte) any {
+	switch typ {
+	case ctTrue:
+		return true
+	case ctFalse:
+		return false
+	case ctI32, ctI64:
+		return d.zigzag()
+	case ctBinary:
+		n := d.uvarint()
+		s := string(d.buf[:n])
+		d.buf = d.buf[n:]
+		return s
+	case ctList:
+		h := d.byte()
+		n, elem := uint64(h>>4), h&0x0f
+		if n == 15 {
+			n = d.uvarint()
+		}
+		list := make([]any, n)
+		for i := range list {
+			if elem == ctTrue {
+				list[i] = d.byte() == ctTrue
+				continue
+			}
+			list[i] = d.value(elem)
+		}
+		return list
+	case ctStruct:
+		s := thriftStruct{}
+		var id int16
+		for {
+			h := d.byte()
+			if h == 0 {
+				return s
+			}
+			if delta := int16(h >> 4); delta != 0 {
+				id += delta
+			} else {
+				id = int16(d.zigzag())
+			}
+			s[id] = d.value(h & 0x0f)
+		}
+	}
+	d.t.Fatalf("unsupported thrift type %d", typ)
+	return nil
+}
+
+func TestWriterLayout(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf,
+		Column{"pair_name", String},
+		Column{"window_start", Timestamp},
+		Column{"average_price", Double},
+	)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	rows := RowGroupRows + 3
+	for i := 0; i < rows; i++ {
+		pair := "BTCUSDT"
+		if i%2 == 1 {
+			pair = "ETHUSDT"
+		}
+		if err := w.Write(pair, start.Add(time.Duration(i)*time.Minute), float64(i)/4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Write("BTCUSDT", "not a time", 1.0); err == nil || !strings.Contains(err.Error(), "window_start") {
+		t.Errorf("Write with a wrong type = %v, want an error naming the column", err)
+	}
+	if err := w.Write("BTCUSDT"); err == nil {
+		t.Error("Write accepted a short row")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, []byte(magic)) || !bytes.HasSuffix(file, []byte(magic)) {
+		t.Fatal("file does not start and end with PAR1")
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-size : len(file)-8]
+	meta := (&decoder{t, footer}).value(ctStruct).(thriftStruct)
+
+	if meta[3] != int64(rows) {
+		t.Errorf("num_rows = %v, want %d", meta[3], rows)
+	}
+	schema := meta[2].([]any)
+	if len(schema) != 4 || schema[0].(thriftStruct)[5] != int64(3) {
+		t.Fatalf("schema = %v, want a root with three children", schema)
+	}
+	for i, want := range []struct {
+		name      string
+		typ       int64
+		converted any
+	}{{"pair_name", typeByteArray, int64(convertedUTF8)}, {"window_start", typeInt64, int64(convertedTimestampMillis)}, {"average_price", typeDouble, nil}} {
+		el := schema[i+1].(thriftStruct)
+		if el[4] != want.name || el[1] != want.typ || el[3] != int64(repetitionRequired) || el[6] != want.converted {
+			t.Errorf("schema element %d = %v, want %+v", i+1, el, want)
+		}
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("%d row groups, want 2", len(groups))
+	}
+	var pairs []string
+	var starts []time.Time
+	var avgs []float64
+	for _, g := range groups {
+		g := g.(thriftStruct)
+		n := int(g[3].(int64))
+		for c, ch := range g[1].([]any) {
+			md := ch.(thriftStruct)[3].(thriftStruct)
+			d := &decoder{t, file[md[9].(int64):]}
+			page := d.value(ctStruct).(thriftStruct)
+			data := d.buf[:page[3].(int64)]
+			if header := page[5].(thriftStruct); header[1] != int64(n) || md[5] != int64(n) {
+				t.Fatalf("column %d holds %v values, want %d", c, header[1], n)
+			}
+			for i := 0; i < n; i++ {
+				switch c {
+				case 0:
+					l := binary.LittleEndian.Uint32(data)
+					pairs = append(pairs, string(data[4:4+l]))
+					data = data[4+l:]
+				case 1:
+					starts = append(starts, time.UnixMilli(int64(binary.LittleEndian.Uint64(data))).UTC())
+					data = data[8:]
+				case 2:
+					avgs = append(avgs, math.Float64frombits(binary.LittleEndian.Uint64(data)))
+					data = data[8:]
+				}
+			}
+		}
+	}
+	if len(pairs) != rows || len(starts) != rows || len(avgs) != rows {
+		t.Fatalf("read back %d/%d/%d values, want %d", len(pairs), len(starts), len(avgs), rows)
+	}
+	for _, i := range []int{0, 1, RowGroupRows - 1, RowGroupRows, rows - 1} {
+		want := "BTCUSDT"
+		if i%2 == 1 {
+			want = "ETHUSDT"
+		}
+		if pairs[i] != want || !starts[i].Equal(start.Add(time.Duration(i)*time.Minute)) || avgs[i] != float64(i)/4 {
+			t.Errorf("row %d = %s %v %v", i, pairs[i], starts[i], avgs[i])
+		}
+	}
+}
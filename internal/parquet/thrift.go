@@ -0,0 +1,91 @@
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol field types.
+const (
+	ctTrue   = 1
+	ctFalse  = 2
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// compact encodes the Thrift compact protocol, which Parquet uses for its
+// page headers and footer. Only what those structures need is covered.
+type compact struct {
+	buf  []byte
+	last []int16 // last field id of each open struct
+}
+
+func (c *compact) field(id int16, typ byte) {
+	n := len(c.last) - 1
+	if delta := id - c.last[n]; delta > 0 && delta <= 15 {
+		c.buf = append(c.buf, byte(delta)<<4|typ)
+	} else {
+		c.buf = append(c.buf, typ)
+		c.varint(int64(id))
+	}
+	c.last[n] = id
+}
+
+func (c *compact) varint(v int64) {
+	c.buf = binary.AppendUvarint(c.buf, uint64(v<<1^v>>63))
+}
+
+func (c *compact) beginStruct() { c.last = append(c.last, 0) }
+
+func (c *compact) endStruct() {
+	c.buf = append(c.buf, 0)
+	c.last = c.last[:len(c.last)-1]
+}
+
+func (c *compact) i32(id int16, v int32) {
+	c.field(id, ctI32)
+	c.varint(int64(v))
+}
+
+func (c *compact) i64(id int16, v int64) {
+	c.field(id, ctI64)
+	c.varint(v)
+}
+
+func (c *compact) bool(id int16, v bool) {
+	if v {
+		c.field(id, ctTrue)
+	} else {
+		c.field(id, ctFalse)
+	}
+}
+
+func (c *compact) string(id int16, s string) {
+	c.field(id, ctBinary)
+	c.stringElem(s)
+}
+
+// list starts a list field of n elements of type elem; the caller writes
+// the elements next.
+func (c *compact) list(id int16, elem byte, n int) {
+	c.field(id, ctList)
+	if n < 15 {
+		c.buf = append(c.buf, byte(n)<<4|elem)
+	} else {
+		c.buf = append(c.buf, 0xf0|elem)
+		c.buf = binary.AppendUvarint(c.buf, uint64(n))
+	}
+}
+
+// structField starts a struct-valued field; close it with endStruct.
+func (c *compact) structField(id int16) {
+	c.field(id, ctStruct)
+	c.beginStruct()
+}
+
+func (c *compact) i32Elem(v int32) { c.varint(int64(v)) }
+
+func (c *compact) stringElem(s string) {
+	c.buf = binary.AppendUvarint(c.buf, uint64(len(s)))
+	c.buf = append(c.buf, s...)
+}